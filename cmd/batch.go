@@ -0,0 +1,305 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"github.com/tomi/media-parser-cli/internal/analyzer"
+	"github.com/tomi/media-parser-cli/internal/detector"
+	"github.com/tomi/media-parser-cli/internal/reporter"
+	"github.com/tomi/media-parser-cli/internal/throttle"
+	"github.com/tomi/media-parser-cli/pkg/ffprobe"
+)
+
+var (
+	batchRecursive     bool
+	batchConcurrency   int
+	batchRateLimit     float64
+	batchMaxConcurrent int
+)
+
+// mediaExtensions are the file extensions batch picks up when walking a
+// directory
+var mediaExtensions = map[string]bool{
+	".mp4": true, ".m4v": true, ".mov": true, ".mkv": true, ".webm": true,
+	".avi": true, ".wmv": true, ".flv": true, ".ts": true, ".m2ts": true,
+	".mts": true, ".mxf": true, ".mpg": true, ".mpeg": true, ".3gp": true,
+	".ogv": true, ".m4a": true, ".mp3": true, ".aac": true, ".flac": true,
+	".wav": true, ".ogg": true, ".opus": true,
+}
+
+var batchCmd = &cobra.Command{
+	Use:   "batch [directory, file or stream URL]...",
+	Short: "Analyze many files or streams and print a combined report",
+	Long: `Batch analyzes every media file in the given directories, plus any files or
+stream URLs named directly, and prints one combined report with per-file
+problem counts.
+
+Inputs that fail to probe are recorded in the report instead of stopping
+the run. Remote inputs can be throttled with --rate-limit and
+--max-concurrent so large scans do not get blocked by a CDN; local files
+are only bound by --concurrency.
+
+Examples:
+  media-parser-cli batch ./clips --recursive
+  media-parser-cli batch ./clips -o csv > report.csv
+  media-parser-cli batch ./clips --fail-on error
+  media-parser-cli batch urls/*.m3u8 https://example.com/live.m3u8 --rate-limit 2`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runBatch,
+}
+
+func init() {
+	rootCmd.AddCommand(batchCmd)
+
+	batchCmd.Flags().BoolVarP(&batchRecursive, "recursive", "r", false, "Walk directories recursively")
+	batchCmd.Flags().IntVarP(&batchConcurrency, "concurrency", "j", 4, "Number of inputs analyzed in parallel")
+	batchCmd.Flags().Float64Var(&batchRateLimit, "rate-limit", 0, "Maximum remote analyses started per second (0 is unlimited)")
+	batchCmd.Flags().IntVar(&batchMaxConcurrent, "max-concurrent", 0, "Maximum remote analyses in flight (0 is bounded only by --concurrency)")
+	batchCmd.Flags().StringVar(&failOn, "fail-on", "", "Exit with status 2 when any file has a problem at or above this severity (info, warning, error, critical)")
+	batchCmd.Flags().StringVar(&outFile, "out-file", "", "Write the report to this file instead of stdout")
+	batchCmd.Flags().IntVar(&timeout, "timeout", 30, "Analysis timeout in seconds, per input")
+}
+
+func runBatch(cmd *cobra.Command, args []string) error {
+	if err := ensureFFprobe(); err != nil {
+		return err
+	}
+
+	csvReport := strings.EqualFold(output, "csv")
+	format := reporter.FormatText
+	if !csvReport {
+		format = getOutputFormat()
+	}
+
+	failOnLevel, checkProblems, err := failOnSeverity()
+	if err != nil {
+		return err
+	}
+	if batchConcurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1")
+	}
+
+	inputs, err := collectBatchInputs(args)
+	if err != nil {
+		return err
+	}
+	if len(inputs) == 0 {
+		return fmt.Errorf("no media files found")
+	}
+
+	overrides, err := parseSeverityOverrides()
+	if err != nil {
+		return err
+	}
+	profile, err := selectedProfile()
+	if err != nil {
+		return err
+	}
+	compatMatrix, err := loadCompatMatrix()
+	if err != nil {
+		return err
+	}
+	if err := validateInputFormat(); err != nil {
+		return err
+	}
+	if err := validateHeaders(); err != nil {
+		return err
+	}
+	probeSize, analyzeDuration, err := probeLimits()
+	if err != nil {
+		return err
+	}
+	streamSelection, err := selectedStream()
+	if err != nil {
+		return err
+	}
+
+	options := analyzer.Options{
+		Timeout:              timeout,
+		ShowVideo:            true,
+		ShowAudio:            true,
+		ShowFormat:           true,
+		Verbose:              verbose,
+		AnalyzePackets:       true,
+		AnalyzeFrames:        true,
+		MaxPackets:           1000, // Same limits as parse
+		MaxFrames:            500,
+		CacheDir:             analysisCacheDir(),
+		SeverityOverrides:    overrides,
+		Profile:              profile,
+		Hash:                 hashInput,
+		ProfileDetectors:     profileDetectors,
+		ShowCommand:          showCommand,
+		CompatMatrix:         compatMatrix,
+		SlowWarningThreshold: slowWarning,
+		PeakBitrateWindow:    peakWindow,
+		MaxProblems:          maxProblems,
+		Streaming:            streaming,
+		InputFormat:          inputFormat,
+		FFprobePath:          ffprobePath,
+		Headers:              httpHeaders,
+		UserAgent:            userAgent,
+		Retries:              retries,
+		RetryDelay:           retryDelay,
+		ProbeSize:            probeSize,
+		AnalyzeDuration:      analyzeDuration,
+		Stream:               streamSelection,
+		DetectorConfig:       detectorConfig(),
+	}
+
+	out, err := openOutFile()
+	if err != nil {
+		return err
+	}
+	if out != os.Stdout {
+		defer out.Close()
+	}
+
+	results := analyzeBatch(cmd.Context(), analyzer.New(options), inputs)
+	if cmd.Context().Err() != nil {
+		return analysisError(cmd, ffprobe.ErrCancelled)
+	}
+	report := reporter.NewBatchReport(results)
+
+	r := reporter.New(reporter.Options{Format: format, Verbose: verbose})
+	r.SetWriter(out)
+	if csvReport {
+		err = r.PrintBatchCSV(report)
+	} else {
+		err = r.PrintBatch(report)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to generate report: %w", err)
+	}
+
+	if checkProblems && batchHasProblemsAtOrAbove(report, failOnLevel) {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		return errChecksFailed
+	}
+	return nil
+}
+
+// collectBatchInputs expands directories into the media files they contain,
+// in lexical order. Files and URLs named directly are kept as given.
+func collectBatchInputs(args []string) ([]string, error) {
+	var inputs []string
+	for _, arg := range args {
+		if strings.Contains(arg, "://") {
+			inputs = append(inputs, arg)
+			continue
+		}
+		stat, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+		if !stat.IsDir() {
+			inputs = append(inputs, arg)
+			continue
+		}
+
+		var found []string
+		err = filepath.WalkDir(arg, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() {
+				if path != arg && !batchRecursive {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if mediaExtensions[strings.ToLower(filepath.Ext(path))] {
+				found = append(found, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", arg, err)
+		}
+		sort.Strings(found)
+		inputs = append(inputs, found...)
+	}
+	return inputs, nil
+}
+
+// analyzeBatch analyzes inputs with up to --concurrency workers, throttling
+// remote inputs. Results keep the order of inputs. Once ctx is cancelled no
+// further inputs are started.
+func analyzeBatch(ctx context.Context, mediaAnalyzer *analyzer.Analyzer, inputs []string) []reporter.BatchResult {
+	results := make([]reporter.BatchResult, len(inputs))
+	limiter := throttle.New(batchRateLimit, batchMaxConcurrent)
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < batchConcurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = analyzeBatchInput(ctx, mediaAnalyzer, limiter, inputs[i])
+			}
+		}()
+	}
+feed:
+	for i := range inputs {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// analyzeBatchInput analyzes a single batch input, recording failures in
+// the result instead of returning them
+func analyzeBatchInput(ctx context.Context, mediaAnalyzer *analyzer.Analyzer, limiter *throttle.Limiter, input string) reporter.BatchResult {
+	result := reporter.BatchResult{Input: input}
+
+	if strings.Contains(input, "://") {
+		release, waited, err := limiter.Acquire(ctx)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		defer release()
+		if verbose && waited > 0 {
+			fmt.Fprintf(os.Stderr, "Throttled %s for %.2fs\n", input, waited.Seconds())
+		}
+	}
+
+	if verbose {
+		fmt.Fprintf(os.Stderr, "Analyzing: %s\n", input)
+	}
+	detailed, err := mediaAnalyzer.AnalyzeWithDetailsContext(ctx, input)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	detailed.RoundTimes(precision)
+	result.Summary = detailed.Summary()
+	return result
+}
+
+// batchHasProblemsAtOrAbove reports whether any analyzed file has a problem
+// at or above severity
+func batchHasProblemsAtOrAbove(report *reporter.BatchReport, severity detector.Severity) bool {
+	for name, count := range report.ProblemCounts {
+		s, err := detector.ParseSeverity(name)
+		if err == nil && count > 0 && s.Rank() >= severity.Rank() {
+			return true
+		}
+	}
+	return false
+}
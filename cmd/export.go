@@ -1,15 +1,17 @@
 package cmd
 
 import (
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
+	"strconv"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/tomi/media-parser-cli/internal/analyzer"
+	"github.com/tomi/media-parser-cli/internal/detector"
 )
 
 var (
@@ -18,13 +20,17 @@ var (
 	exportFrames   bool
 	exportProblems bool
 	exportBitrate  bool
+	exportGOPsCSV  bool
+	exportNFOFile  bool
+	exportQuality  bool
+	exportSVG      bool
 	exportAll      bool
 	maxPackets     int
 	maxFrames      int
 )
 
 var exportCmd = &cobra.Command{
-	Use:   "export [file or stream URL]",
+	Use:   "export [file, stream URL or -]",
 	Short: "Export detailed media analysis to JSON files",
 	Long: `Export analyzes a media file or stream and saves detailed information to JSON files.
 	
@@ -34,6 +40,9 @@ The export command creates multiple JSON files in the specified directory:
 - packets.json: Packet-level data (optional)
 - frames.json: Frame-level data (optional)
 - bitrate_timeline.json: Bitrate over time (optional)
+- bitrate_timeline.svg: Bitrate over time as an SVG chart (optional)
+- media.nfo: Kodi/Jellyfin-style stream details (optional)
+- quality_timeline.csv: Per-second frame statistics (optional)
 
 This is useful for:
 - Detailed debugging and analysis
@@ -45,7 +54,7 @@ Examples:
   media-parser-cli export video.mp4 -d ./analysis
   media-parser-cli export stream.m3u8 -d ./reports --export-all
   media-parser-cli export video.mp4 -d ./debug --export-frames --max-frames 1000`,
-	Args: cobra.ExactArgs(1),
+	Args: cobra.MaximumNArgs(1),
 	RunE: runExport,
 }
 
@@ -57,19 +66,35 @@ func init() {
 	exportCmd.Flags().BoolVar(&exportFrames, "export-frames", false, "Export frame information")
 	exportCmd.Flags().BoolVar(&exportProblems, "export-problems", true, "Export detected problems")
 	exportCmd.Flags().BoolVar(&exportBitrate, "export-bitrate", false, "Export bitrate timeline")
+	exportCmd.Flags().BoolVar(&exportGOPsCSV, "export-gops-csv", false, "Export per-GOP statistics as CSV (requires frame analysis)")
+	exportCmd.Flags().BoolVar(&exportSVG, "export-bitrate-svg", false, "Render the bitrate timeline as an SVG line chart")
+	exportCmd.Flags().BoolVar(&exportNFOFile, "export-nfo", false, "Export stream details as a Kodi/Jellyfin-style NFO file")
+	exportCmd.Flags().BoolVar(&exportQuality, "export-quality-csv", false, "Export per-second frame statistics as CSV (requires frame analysis)")
 	exportCmd.Flags().BoolVar(&exportAll, "export-all", false, "Export all available information")
 	exportCmd.Flags().IntVar(&maxPackets, "max-packets", 10000, "Maximum number of packets to export")
 	exportCmd.Flags().IntVar(&maxFrames, "max-frames", 5000, "Maximum number of frames to export")
+	exportCmd.Flags().StringVar(&failOn, "fail-on", "", "Exit with status 2 when a problem is at or above this severity (info, warning, error, critical)")
 }
 
 func runExport(cmd *cobra.Command, args []string) error {
-	input := args[0]
+	input, err := inputArg(args)
+	if err != nil {
+		return err
+	}
+
+	if err := ensureFFprobe(); err != nil {
+		return err
+	}
 
 	if exportAll {
 		exportPackets = true
 		exportFrames = true
 		exportProblems = true
 		exportBitrate = true
+		exportGOPsCSV = true
+		exportNFOFile = true
+		exportQuality = true
+		exportSVG = true
 	}
 
 	// Create export directory
@@ -88,25 +113,75 @@ func runExport(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create export subdirectory: %w", err)
 	}
 
+	overrides, err := parseSeverityOverrides()
+	if err != nil {
+		return err
+	}
+	profile, err := selectedProfile()
+	if err != nil {
+		return err
+	}
+
+	compatMatrix, err := loadCompatMatrix()
+	if err != nil {
+		return err
+	}
+	if err := validateInputFormat(); err != nil {
+		return err
+	}
+	if err := validateHeaders(); err != nil {
+		return err
+	}
+	probeSize, analyzeDuration, err := probeLimits()
+	if err != nil {
+		return err
+	}
+	streamSelection, err := selectedStream()
+	if err != nil {
+		return err
+	}
+
 	// Analyze media
 	options := analyzer.Options{
-		Timeout:       timeout,
-		ShowVideo:     true,
-		ShowAudio:     true,
-		ShowFormat:    true,
-		ShowStreams:   true,
-		Verbose:       verbose,
-		AnalyzePackets: exportPackets,
-		AnalyzeFrames:  exportFrames,
-		MaxPackets:    maxPackets,
-		MaxFrames:     maxFrames,
-	}
-
-	analyzer := analyzer.New(options)
-	result, err := analyzer.AnalyzeWithDetails(input)
+		Timeout:              timeout,
+		ShowVideo:            true,
+		ShowAudio:            true,
+		ShowFormat:           true,
+		ShowStreams:          true,
+		Verbose:              verbose,
+		AnalyzePackets:       exportPackets || exportSVG,
+		AnalyzeFrames:        exportFrames || exportGOPsCSV || exportQuality,
+		MaxPackets:           maxPackets,
+		MaxFrames:            maxFrames,
+		CacheDir:             analysisCacheDir(),
+		SeverityOverrides:    overrides,
+		Profile:              profile,
+		Hash:                 hashInput,
+		ProfileDetectors:     profileDetectors,
+		ShowCommand:          showCommand,
+		CompatMatrix:         compatMatrix,
+		SlowWarningThreshold: slowWarning,
+		PeakBitrateWindow:    peakWindow,
+		MaxProblems:          maxProblems,
+		Streaming:            streaming,
+		InputFormat:          inputFormat,
+		FFprobePath:          ffprobePath,
+		Headers:              httpHeaders,
+		UserAgent:            userAgent,
+		Retries:              retries,
+		RetryDelay:           retryDelay,
+		ProbeSize:            probeSize,
+		AnalyzeDuration:      analyzeDuration,
+		Stream:               streamSelection,
+		DetectorConfig:       detectorConfig(),
+	}
+
+	mediaAnalyzer := analyzer.New(options)
+	result, err := mediaAnalyzer.AnalyzeWithDetailsContext(cmd.Context(), input)
 	if err != nil {
-		return fmt.Errorf("failed to analyze media: %w", err)
+		return analysisError(cmd, err)
 	}
+	result.RoundTimes(precision)
 
 	// Export basic media info
 	if err := exportJSON(filepath.Join(exportSubDir, "media_info.json"), result.MediaInfo); err != nil {
@@ -138,7 +213,7 @@ func runExport(cmd *cobra.Command, args []string) error {
 		fmt.Printf("✓ Exported %d frames to %s\n", len(result.Frames), filepath.Join(exportSubDir, "frames.json"))
 
 		// Export frame visualization (eyecard-style)
-		if frameViz := generateFrameVisualization(result.Frames); frameViz != nil {
+		if frameViz := analyzer.GenerateFrameVisualization(result.Frames); frameViz != nil {
 			if err := exportJSON(filepath.Join(exportSubDir, "frame_visualization.json"), frameViz); err != nil {
 				return fmt.Errorf("failed to export frame visualization: %w", err)
 			}
@@ -146,6 +221,28 @@ func runExport(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Export per-GOP statistics
+	gopsCSVCreated := false
+	if exportGOPsCSV && len(result.Frames) > 0 {
+		if frameViz := analyzer.GenerateFrameVisualization(result.Frames); frameViz != nil && len(frameViz.GOPStructure) > 0 {
+			if err := exportGOPCSV(filepath.Join(exportSubDir, "gops.csv"), frameViz.GOPStructure); err != nil {
+				return fmt.Errorf("failed to export GOP statistics: %w", err)
+			}
+			gopsCSVCreated = true
+			fmt.Printf("✓ Exported %d GOPs to %s\n", len(frameViz.GOPStructure), filepath.Join(exportSubDir, "gops.csv"))
+		}
+	}
+
+	// Export per-second quality timeline
+	qualityCSVCreated := false
+	if exportQuality && len(result.QualityTimeline) > 0 {
+		if err := exportQualityCSV(filepath.Join(exportSubDir, "quality_timeline.csv"), result.QualityTimeline); err != nil {
+			return fmt.Errorf("failed to export quality timeline: %w", err)
+		}
+		qualityCSVCreated = true
+		fmt.Printf("✓ Exported %d seconds of quality metrics to %s\n", len(result.QualityTimeline), filepath.Join(exportSubDir, "quality_timeline.csv"))
+	}
+
 	// Export bitrate timeline
 	if exportBitrate && len(result.BitrateTimeline) > 0 {
 		if err := exportJSON(filepath.Join(exportSubDir, "bitrate_timeline.json"), result.BitrateTimeline); err != nil {
@@ -154,6 +251,25 @@ func runExport(cmd *cobra.Command, args []string) error {
 		fmt.Printf("✓ Exported bitrate timeline to %s\n", filepath.Join(exportSubDir, "bitrate_timeline.json"))
 	}
 
+	// Render bitrate chart
+	svgCreated := false
+	if exportSVG && len(result.BitrateTimeline) > 0 {
+		title := fmt.Sprintf("Bitrate over time: %s", filepath.Base(input))
+		if err := exportBitrateSVG(filepath.Join(exportSubDir, "bitrate_timeline.svg"), title, result.BitrateTimeline); err != nil {
+			return fmt.Errorf("failed to export bitrate chart: %w", err)
+		}
+		svgCreated = true
+		fmt.Printf("✓ Exported bitrate chart to %s\n", filepath.Join(exportSubDir, "bitrate_timeline.svg"))
+	}
+
+	// Export media server metadata
+	if exportNFOFile {
+		if err := exportNFO(filepath.Join(exportSubDir, "media.nfo"), input, result.MediaInfo); err != nil {
+			return fmt.Errorf("failed to export NFO: %w", err)
+		}
+		fmt.Printf("✓ Exported NFO metadata to %s\n", filepath.Join(exportSubDir, "media.nfo"))
+	}
+
 	// Create summary file
 	summary := map[string]interface{}{
 		"analysis_timestamp": timestamp,
@@ -166,6 +282,10 @@ func runExport(cmd *cobra.Command, args []string) error {
 			"frames.json":            exportFrames && len(result.Frames) > 0,
 			"frame_visualization.json": exportFrames && len(result.Frames) > 0,
 			"bitrate_timeline.json":  exportBitrate && len(result.BitrateTimeline) > 0,
+			"gops.csv":               gopsCSVCreated,
+			"media.nfo":              exportNFOFile,
+			"quality_timeline.csv":   qualityCSVCreated,
+			"bitrate_timeline.svg":   svgCreated,
 		},
 		"statistics": map[string]int{
 			"problems_found": len(result.Problems),
@@ -182,7 +302,10 @@ func runExport(cmd *cobra.Command, args []string) error {
 	fmt.Printf("Analysis exported to: %s\n", exportSubDir)
 	fmt.Printf("Total files created: %d\n", countCreatedFiles(summary["files_created"].(map[string]bool)))
 
-	return nil
+	notifyWebhook(result)
+	runOnComplete(result)
+
+	return checkFailOn(cmd, result.Problems)
 }
 
 func exportJSON(filename string, data interface{}) error {
@@ -197,114 +320,63 @@ func exportJSON(filename string, data interface{}) error {
 	return encoder.Encode(data)
 }
 
-func countCreatedFiles(files map[string]bool) int {
-	count := 0
-	for _, created := range files {
-		if created {
-			count++
-		}
-	}
-	return count
-}
-
-type FrameVisualization struct {
-	TotalFrames int                    `json:"total_frames"`
-	Duration    float64                `json:"duration"`
-	FrameTypes  map[string]int         `json:"frame_types"`
-	GOPStructure []GOPInfo             `json:"gop_structure"`
-	Timeline    []FrameTimelineEntry   `json:"timeline"`
-}
-
-type GOPInfo struct {
-	StartTime   float64 `json:"start_time"`
-	EndTime     float64 `json:"end_time"`
-	FrameCount  int     `json:"frame_count"`
-	IFrames     int     `json:"i_frames"`
-	PFrames     int     `json:"p_frames"`
-	BFrames     int     `json:"b_frames"`
-}
-
-type FrameTimelineEntry struct {
-	Time      float64 `json:"time"`
-	FrameType string  `json:"frame_type"`
-	Size      int     `json:"size"`
-	KeyFrame  bool    `json:"key_frame"`
-}
-
-func generateFrameVisualization(frames []analyzer.FrameData) *FrameVisualization {
-	if len(frames) == 0 {
-		return nil
+func exportGOPCSV(filename string, gops []detector.GOPInfo) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
 	}
+	defer file.Close()
 
-	viz := &FrameVisualization{
-		TotalFrames: len(frames),
-		FrameTypes:  make(map[string]int),
-		GOPStructure: make([]GOPInfo, 0),
-		Timeline:    make([]FrameTimelineEntry, 0),
+	writer := csv.NewWriter(file)
+	writer.Write([]string{"gop_index", "start_time", "end_time", "duration", "frame_count", "i_frames", "p_frames", "b_frames", "bytes", "keyframe_interval"})
+	for i, gop := range gops {
+		writer.Write([]string{
+			strconv.Itoa(i),
+			strconv.FormatFloat(gop.StartTime, 'f', 6, 64),
+			strconv.FormatFloat(gop.EndTime, 'f', 6, 64),
+			strconv.FormatFloat(gop.EndTime-gop.StartTime, 'f', 6, 64),
+			strconv.Itoa(gop.FrameCount),
+			strconv.Itoa(gop.IFrames),
+			strconv.Itoa(gop.PFrames),
+			strconv.Itoa(gop.BFrames),
+			strconv.FormatInt(gop.Bytes, 10),
+			strconv.FormatFloat(gop.Duration, 'f', 6, 64),
+		})
 	}
+	writer.Flush()
+	return writer.Error()
+}
 
-	// Find video frames only
-	videoFrames := make([]analyzer.FrameData, 0)
-	for _, frame := range frames {
-		if strings.ToLower(frame.MediaType) == "video" {
-			videoFrames = append(videoFrames, frame)
-		}
+func exportQualityCSV(filename string, timeline []detector.QualitySecond) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
 	}
+	defer file.Close()
 
-	if len(videoFrames) == 0 {
-		return viz
+	writer := csv.NewWriter(file)
+	writer.Write([]string{"second", "frames", "key_frames", "bytes", "i_frames", "p_frames", "b_frames"})
+	for _, second := range timeline {
+		writer.Write([]string{
+			strconv.Itoa(second.Second),
+			strconv.Itoa(second.Frames),
+			strconv.Itoa(second.KeyFrames),
+			strconv.FormatInt(second.Bytes, 10),
+			strconv.Itoa(second.IFrames),
+			strconv.Itoa(second.PFrames),
+			strconv.Itoa(second.BFrames),
+		})
 	}
+	writer.Flush()
+	return writer.Error()
+}
 
-	// Count frame types and build timeline
-	currentGOP := GOPInfo{StartTime: videoFrames[0].PTS}
-	
-	for i, frame := range videoFrames {
-		// Count frame types
-		if frame.PictType != "" {
-			viz.FrameTypes[frame.PictType]++
-			
-			switch frame.PictType {
-			case "I":
-				currentGOP.IFrames++
-			case "P":
-				currentGOP.PFrames++
-			case "B":
-				currentGOP.BFrames++
-			}
-		}
-
-		// Add to timeline (sample every N frames for large files)
-		sampleRate := len(videoFrames) / 1000 // Max 1000 timeline entries
-		if sampleRate < 1 {
-			sampleRate = 1
-		}
-		if i%sampleRate == 0 {
-			viz.Timeline = append(viz.Timeline, FrameTimelineEntry{
-				Time:      frame.PTS,
-				FrameType: frame.PictType,
-				Size:      frame.Size,
-				KeyFrame:  frame.KeyFrame,
-			})
-		}
-
-		// Detect GOP boundaries (I-frame starts new GOP)
-		if frame.KeyFrame && i > 0 {
-			currentGOP.EndTime = videoFrames[i-1].PTS
-			currentGOP.FrameCount = currentGOP.IFrames + currentGOP.PFrames + currentGOP.BFrames
-			viz.GOPStructure = append(viz.GOPStructure, currentGOP)
-			currentGOP = GOPInfo{StartTime: frame.PTS, IFrames: 1}
-		} else {
-			currentGOP.FrameCount++
+func countCreatedFiles(files map[string]bool) int {
+	count := 0
+	for _, created := range files {
+		if created {
+			count++
 		}
 	}
-
-	// Add final GOP
-	if currentGOP.FrameCount > 0 {
-		currentGOP.EndTime = videoFrames[len(videoFrames)-1].PTS
-		viz.GOPStructure = append(viz.GOPStructure, currentGOP)
-	}
-
-	viz.Duration = videoFrames[len(videoFrames)-1].PTS
-
-	return viz
-}
\ No newline at end of file
+	return count
+}
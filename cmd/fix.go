@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/tomi/media-parser-cli/internal/analyzer"
+	"github.com/tomi/media-parser-cli/internal/fixer"
+	"github.com/tomi/media-parser-cli/pkg/ffprobe"
+)
+
+var (
+	fixOutput string
+	fixYes    bool
+)
+
+var fixCmd = &cobra.Command{
+	Use:   "fix [file] -o [output file]",
+	Short: "Remux a file with ffmpeg to fix common problems losslessly",
+	Long: `Fix analyzes a media file and remuxes it with ffmpeg to resolve problems
+that can be fixed without re-encoding.
+
+Depending on the detected problems and the output container, fix will:
+- Move the moov atom to the front of MP4/MOV files (+faststart)
+- Remux into MP4 when the streams are MP4-compatible
+- Shift negative start timestamps to zero
+- Drop subtitle/data streams the target container cannot carry
+
+Streams are always copied, never re-encoded. If a problem can only be fixed
+by re-encoding, fix refuses to run. The ffmpeg command is printed first and
+only executed when --yes is given.
+
+Examples:
+  media-parser-cli fix video.mkv -o video.mp4
+  media-parser-cli fix video.mp4 -o fixed.mp4 --yes`,
+	Args: cobra.ExactArgs(1),
+	RunE: runFix,
+}
+
+func init() {
+	rootCmd.AddCommand(fixCmd)
+
+	// Shadows the global --output format flag, which has no meaning here
+	fixCmd.Flags().StringVarP(&fixOutput, "output", "o", "", "Output file (required)")
+	fixCmd.Flags().BoolVar(&fixYes, "yes", false, "Run the ffmpeg command instead of only printing it")
+	fixCmd.Flags().IntVar(&timeout, "timeout", 30, "Analysis timeout in seconds")
+	fixCmd.MarkFlagRequired("output")
+}
+
+func runFix(cmd *cobra.Command, args []string) error {
+	input := args[0]
+
+	if input == ffprobe.StdinInput {
+		return fmt.Errorf("fix cannot read stdin; save the input to a file first")
+	}
+	if fixOutput == input {
+		return fmt.Errorf("output must differ from the input file")
+	}
+	// The remux copies every stream, so every stream must be checked
+	if cmd.Flags().Changed("stream") {
+		return fmt.Errorf("fix remuxes all streams and cannot be limited to one with --stream")
+	}
+
+	if err := ensureFFprobe(); err != nil {
+		return err
+	}
+
+	overrides, err := parseSeverityOverrides()
+	if err != nil {
+		return err
+	}
+	profile, err := selectedProfile()
+	if err != nil {
+		return err
+	}
+
+	compatMatrix, err := loadCompatMatrix()
+	if err != nil {
+		return err
+	}
+	if err := validateInputFormat(); err != nil {
+		return err
+	}
+	if err := validateHeaders(); err != nil {
+		return err
+	}
+	probeSize, analyzeDuration, err := probeLimits()
+	if err != nil {
+		return err
+	}
+
+	options := analyzer.Options{
+		Timeout:              timeout,
+		ShowVideo:            true,
+		ShowAudio:            true,
+		ShowFormat:           true,
+		ShowStreams:          true,
+		Verbose:              verbose,
+		AnalyzePackets:       true,
+		AnalyzeFrames:        false,
+		MaxPackets:           1000,
+		CacheDir:             analysisCacheDir(),
+		SeverityOverrides:    overrides,
+		Profile:              profile,
+		Hash:                 hashInput,
+		ProfileDetectors:     profileDetectors,
+		ShowCommand:          showCommand,
+		CompatMatrix:         compatMatrix,
+		SlowWarningThreshold: slowWarning,
+		PeakBitrateWindow:    peakWindow,
+		MaxProblems:          maxProblems,
+		Streaming:            streaming,
+		InputFormat:          inputFormat,
+		FFprobePath:          ffprobePath,
+		Headers:              httpHeaders,
+		UserAgent:            userAgent,
+		Retries:              retries,
+		RetryDelay:           retryDelay,
+		ProbeSize:            probeSize,
+		AnalyzeDuration:      analyzeDuration,
+		DetectorConfig:       detectorConfig(),
+	}
+
+	result, err := analyzer.New(options).AnalyzeWithDetailsContext(cmd.Context(), input)
+	if err != nil {
+		return analysisError(cmd, err)
+	}
+
+	plan, err := fixer.NewPlan(result, fixOutput)
+	if err != nil {
+		return fmt.Errorf("cannot fix losslessly: %w", err)
+	}
+
+	fmt.Println("Planned fixes:")
+	for _, reason := range plan.Reasons {
+		fmt.Printf("  - %s\n", reason)
+	}
+	fmt.Printf("\n%s\n", plan)
+
+	if !fixYes {
+		fmt.Fprintln(os.Stderr, "\nDry run: re-run with --yes to execute this command")
+		return nil
+	}
+
+	if err := plan.Run(cmd.Context()); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Wrote %s\n", fixOutput)
+	return nil
+}
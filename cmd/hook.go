@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"text/template"
+
+	"github.com/tomi/media-parser-cli/internal/analyzer"
+)
+
+var onComplete string
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&onComplete, "on-complete", "", "Shell command template to run after analysis, e.g. \"notify {{quote .Input}} {{.Status}}\"")
+}
+
+// hookData holds the template variables available to --on-complete
+type hookData struct {
+	Input      string
+	Status     string // OK, or FAIL when there are error or critical problems
+	Format     string
+	Duration   float64
+	VideoCodec string
+	Width      int
+	Height     int
+	AudioCodec string
+	Problems   int
+	Errors     int // Error and critical problems
+	Warnings   int
+	ResultFile string // Temporary file holding the full analysis JSON
+}
+
+// hookFuncs are the functions available to --on-complete templates
+var hookFuncs = template.FuncMap{
+	"quote": shellQuote,
+}
+
+// shellQuote quotes s as a single POSIX shell word
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// runOnComplete runs the --on-complete command, if any, with the analysis
+// JSON on stdin and in a temporary file. The hook's output goes to stderr so
+// it never mixes with the report, and its exit status is reported on stderr;
+// hook failures never fail the command.
+func runOnComplete(result *analyzer.DetailedAnalysis) {
+	if onComplete == "" {
+		return
+	}
+
+	tmpl, err := template.New("on-complete").Funcs(hookFuncs).Parse(onComplete)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --on-complete template: %v\n", err)
+		return
+	}
+
+	payload, err := json.Marshal(result)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "on-complete hook: failed to encode analysis: %v\n", err)
+		return
+	}
+
+	resultFile, err := os.CreateTemp("", "media-parser-*.json")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "on-complete hook: failed to create result file: %v\n", err)
+		return
+	}
+	defer os.Remove(resultFile.Name())
+	_, err = resultFile.Write(payload)
+	if closeErr := resultFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "on-complete hook: failed to write result file: %v\n", err)
+		return
+	}
+
+	summary := result.Summary()
+	data := hookData{
+		Input:      summary.Input,
+		Status:     "OK",
+		Format:     summary.Format,
+		Duration:   summary.Duration,
+		VideoCodec: summary.VideoCodec,
+		Width:      summary.Width,
+		Height:     summary.Height,
+		AudioCodec: summary.AudioCodec,
+		Problems:   len(result.Problems),
+		Errors:     summary.Errors(),
+		Warnings:   summary.ProblemCounts["warning"],
+		ResultFile: resultFile.Name(),
+	}
+	if data.Errors > 0 {
+		data.Status = "FAIL"
+	}
+
+	var command bytes.Buffer
+	if err := tmpl.Execute(&command, data); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --on-complete template: %v\n", err)
+		return
+	}
+
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+	hook := exec.Command(shell, flag, command.String())
+	hook.Stdin = bytes.NewReader(payload)
+	hook.Stdout = os.Stderr
+	hook.Stderr = os.Stderr
+
+	err = hook.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		fmt.Fprintln(os.Stderr, "on-complete hook exited with status 0")
+	case errors.As(err, &exitErr):
+		fmt.Fprintf(os.Stderr, "on-complete hook exited with status %d\n", exitErr.ExitCode())
+	default:
+		fmt.Fprintf(os.Stderr, "on-complete hook failed to run: %v\n", err)
+	}
+}
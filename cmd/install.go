@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/tomi/media-parser-cli/pkg/ffprobe"
+)
+
+var installFFmpeg bool
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&installFFmpeg, "install-ffmpeg", false, "Offer to install FFmpeg with the system package manager when ffprobe is missing")
+}
+
+// ensureFFprobe verifies ffprobe is available before analysis starts. With
+// --install-ffmpeg it offers to run the platform's install command.
+func ensureFFprobe() error {
+	probe := ffprobe.NewWithBinary(ffprobePath)
+	err := probe.CheckInstalled()
+	// Installing from a package manager would not fix a custom --ffprobe-path
+	if err == nil || !installFFmpeg || ffprobePath != "" {
+		return err
+	}
+
+	command := ffprobe.InstallCommand(runtime.GOOS)
+	if command == nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "ffprobe was not found. Run `%s` now? [y/N] ", strings.Join(command, " "))
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+		return err
+	}
+
+	install := exec.Command(command[0], command[1:]...)
+	install.Stdin = os.Stdin
+	install.Stdout = os.Stderr
+	install.Stderr = os.Stderr
+	if runErr := install.Run(); runErr != nil {
+		return fmt.Errorf("FFmpeg install failed: %w", runErr)
+	}
+	return probe.CheckInstalled()
+}
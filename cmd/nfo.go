@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tomi/media-parser-cli/internal/analyzer"
+)
+
+// nfoMovie is the root of a Kodi-style NFO file. Kodi, Jellyfin and Plex
+// (via XBMCnfoMoviesImporter) read the <fileinfo><streamdetails> block.
+type nfoMovie struct {
+	XMLName  xml.Name    `xml:"movie"`
+	Title    string      `xml:"title"`
+	Runtime  int         `xml:"runtime,omitempty"` // Minutes
+	FileInfo nfoFileInfo `xml:"fileinfo"`
+}
+
+type nfoFileInfo struct {
+	StreamDetails nfoStreamDetails `xml:"streamdetails"`
+}
+
+type nfoStreamDetails struct {
+	Video    []nfoVideo    `xml:"video"`
+	Audio    []nfoAudio    `xml:"audio"`
+	Subtitle []nfoSubtitle `xml:"subtitle"`
+}
+
+type nfoVideo struct {
+	Codec             string `xml:"codec"`
+	Aspect            string `xml:"aspect,omitempty"`
+	Width             int    `xml:"width"`
+	Height            int    `xml:"height"`
+	DurationInSeconds int    `xml:"durationinseconds,omitempty"`
+	Bitrate           int64  `xml:"bitrate,omitempty"`
+}
+
+type nfoAudio struct {
+	Codec    string `xml:"codec"`
+	Language string `xml:"language,omitempty"`
+	Channels int    `xml:"channels"`
+	Bitrate  int64  `xml:"bitrate,omitempty"`
+}
+
+type nfoSubtitle struct {
+	Language string `xml:"language"`
+}
+
+// buildNFO maps an analysis onto the NFO stream details structure
+func buildNFO(input string, info *analyzer.MediaInfo) *nfoMovie {
+	movie := &nfoMovie{
+		Title: strings.TrimSuffix(filepath.Base(input), filepath.Ext(input)),
+	}
+
+	duration := 0.0
+	if info.Format != nil {
+		duration = info.Format.Duration
+	}
+	if duration > 0 {
+		movie.Runtime = int(math.Round(duration / 60))
+	}
+
+	details := &movie.FileInfo.StreamDetails
+	if video := info.VideoStream; video != nil {
+		entry := nfoVideo{
+			Codec:             video.Codec,
+			Width:             video.Width,
+			Height:            video.Height,
+			DurationInSeconds: int(math.Round(duration)),
+			Bitrate:           video.Bitrate,
+		}
+		if video.Height > 0 {
+			entry.Aspect = fmt.Sprintf("%.2f", float64(video.Width)/float64(video.Height))
+		}
+		details.Video = append(details.Video, entry)
+	}
+
+	for _, audio := range info.AudioStreams {
+		details.Audio = append(details.Audio, nfoAudio{
+			Codec:    audio.Codec,
+			Language: audio.Language,
+			Channels: audio.Channels,
+			Bitrate:  audio.Bitrate,
+		})
+	}
+
+	for _, stream := range info.Streams {
+		if stream.Type == "subtitle" && stream.Tags["language"] != "" {
+			details.Subtitle = append(details.Subtitle, nfoSubtitle{Language: stream.Tags["language"]})
+		}
+	}
+
+	return movie
+}
+
+func exportNFO(filename, input string, info *analyzer.MediaInfo) error {
+	data, err := xml.MarshalIndent(buildNFO(input, info), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	content := []byte(xml.Header)
+	content = append(content, data...)
+	content = append(content, '\n')
+	return os.WriteFile(filename, content, 0644)
+}
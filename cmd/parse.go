@@ -1,12 +1,14 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/tomi/media-parser-cli/internal/analyzer"
+	"github.com/tomi/media-parser-cli/internal/detector"
 	"github.com/tomi/media-parser-cli/internal/reporter"
 )
 
@@ -18,10 +20,18 @@ var (
 	showProblems bool
 	showAll      bool
 	timeout      int
+	sinceFile    string
+	ndjsonSum    bool
+	fingerprint  bool
+	oneline      bool
+	canonical    bool
+	minSeverity  string
+	sections     []string
+	showAllAudio bool
 )
 
 var parseCmd = &cobra.Command{
-	Use:   "parse [file or stream URL]",
+	Use:   "parse [file, stream URL or -]",
 	Short: "Parse and analyze a video file or stream",
 	Long: `Parse analyzes a video file or stream URL and provides detailed media information.
 	
@@ -30,13 +40,16 @@ Supported inputs:
 - HTTP/HTTPS streams (HLS, DASH, direct media URLs)
 - RTMP/RTSP streams
 - Network file paths
+- Standard input, as "-" or when no input is given
 
 Examples:
   media-parser-cli parse video.mp4
   media-parser-cli parse https://example.com/stream.m3u8
   media-parser-cli parse rtmp://server/live/stream
-  media-parser-cli parse --show-all video.mp4 -o json`,
-	Args: cobra.ExactArgs(1),
+  media-parser-cli parse --show-all video.mp4 -o json
+  media-parser-cli parse --fingerprint video.mp4 -o json
+  cat video.ts | media-parser-cli parse -`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: runParse,
 }
 
@@ -48,12 +61,29 @@ func init() {
 	parseCmd.Flags().BoolVar(&showFormat, "show-format", true, "Show container format information")
 	parseCmd.Flags().BoolVar(&showStreams, "show-streams", false, "Show all stream details")
 	parseCmd.Flags().BoolVar(&showProblems, "show-problems", true, "Show detected problems and warnings")
+	parseCmd.Flags().BoolVar(&showAllAudio, "show-all-audio", false, "List every audio track with its language")
 	parseCmd.Flags().BoolVar(&showAll, "show-all", false, "Show all available information")
 	parseCmd.Flags().IntVar(&timeout, "timeout", 30, "Analysis timeout in seconds")
+	parseCmd.Flags().BoolVar(&ndjsonSum, "ndjson-summary", false, "Print a single compact JSON summary line instead of the report")
+	parseCmd.Flags().StringSliceVar(&sections, "section", nil, "Only print these sections ("+strings.Join(reporter.SectionNames, ", ")+")")
+	parseCmd.Flags().StringVar(&sinceFile, "since", "", "Only report problems not present in this baseline problems.json")
+	parseCmd.Flags().StringVar(&minSeverity, "min-severity", "", "Only report problems at or above this severity (info, warning, error, critical)")
+	parseCmd.Flags().StringVar(&failOn, "fail-on", "", "Exit with status 2 when a reported problem is at or above this severity (info, warning, error, critical)")
+	parseCmd.Flags().StringVar(&outFile, "out-file", "", "Write the report to this file instead of stdout")
+	parseCmd.Flags().BoolVar(&canonical, "canonical", false, "Print a stable, sorted key=value report for committing and diffing")
+	parseCmd.Flags().BoolVar(&oneline, "oneline", false, "Print one OK/FAIL status line and exit non-zero on errors")
+	parseCmd.Flags().BoolVar(&fingerprint, "fingerprint", false, "Analyze metadata only and emit a parameter fingerprint for dedup/grouping")
 }
 
 func runParse(cmd *cobra.Command, args []string) error {
-	input := args[0]
+	input, err := inputArg(args)
+	if err != nil {
+		return err
+	}
+
+	if err := ensureFFprobe(); err != nil {
+		return err
+	}
 
 	if showAll {
 		showVideo = true
@@ -61,71 +91,258 @@ func runParse(cmd *cobra.Command, args []string) error {
 		showFormat = true
 		showStreams = true
 		showProblems = true
+		showAllAudio = true
+	}
+
+	// Fingerprinting only needs stream metadata, so skip packet/frame analysis
+	if fingerprint {
+		showProblems = false
+	}
+
+	// Summary lines carry per-severity problem counts, and --fail-on checks
+	// problems, so detection must run
+	if ndjsonSum || oneline || failOn != "" {
+		showProblems = true
+	}
+	if _, _, err := failOnSeverity(); err != nil {
+		return err
+	}
+
+	// GitHub annotations are built from problems
+	if strings.EqualFold(output, "github") {
+		showProblems = true
+	}
+
+	selectedSections, err := reporter.ValidateSections(sections)
+	if err != nil {
+		return err
+	}
+	severityFloor := detector.SeverityInfo
+	if minSeverity != "" {
+		if severityFloor, err = detector.ParseSeverity(minSeverity); err != nil {
+			return err
+		}
+	}
+	for _, section := range selectedSections {
+		switch section {
+		case reporter.SectionFormat:
+			showFormat = true
+		case reporter.SectionVideo:
+			showVideo = true
+		case reporter.SectionAudio:
+			showAudio = true
+		case reporter.SectionStreams:
+			showStreams = true
+		case reporter.SectionProblems:
+			showProblems = true
+		}
 	}
 
 	if verbose {
 		fmt.Fprintf(os.Stderr, "Analyzing: %s\n", input)
 	}
 
+	overrides, err := parseSeverityOverrides()
+	if err != nil {
+		return err
+	}
+	profile, err := selectedProfile()
+	if err != nil {
+		return err
+	}
+
+	compatMatrix, err := loadCompatMatrix()
+	if err != nil {
+		return err
+	}
+	if err := validateInputFormat(); err != nil {
+		return err
+	}
+	if err := validateHeaders(); err != nil {
+		return err
+	}
+	probeSize, analyzeDuration, err := probeLimits()
+	if err != nil {
+		return err
+	}
+	streamSelection, err := selectedStream()
+	if err != nil {
+		return err
+	}
+
+	out, err := openOutFile()
+	if err != nil {
+		return err
+	}
+	if out != os.Stdout {
+		defer out.Close()
+	}
+
 	options := analyzer.Options{
-		Timeout:        timeout,
-		ShowVideo:      showVideo,
-		ShowAudio:      showAudio,
-		ShowFormat:     showFormat,
-		ShowStreams:    showStreams,
-		Verbose:        verbose,
-		AnalyzePackets: showProblems, // Analyze packets/frames for problem detection
-		AnalyzeFrames:  showProblems,
-		MaxPackets:     1000, // Limit for quick analysis
-		MaxFrames:      500,
-	}
-
-	analyzer := analyzer.New(options)
+		Timeout:              timeout,
+		ShowVideo:            showVideo,
+		ShowAudio:            showAudio,
+		ShowFormat:           showFormat,
+		ShowStreams:          showStreams,
+		Verbose:              verbose,
+		AnalyzePackets:       showProblems, // Analyze packets/frames for problem detection
+		AnalyzeFrames:        showProblems,
+		MaxPackets:           1000, // Limit for quick analysis
+		MaxFrames:            500,
+		CacheDir:             analysisCacheDir(),
+		SeverityOverrides:    overrides,
+		Profile:              profile,
+		Hash:                 hashInput,
+		Fingerprint:          fingerprint,
+		ProfileDetectors:     profileDetectors,
+		ShowCommand:          showCommand,
+		CompatMatrix:         compatMatrix,
+		SlowWarningThreshold: slowWarning,
+		PeakBitrateWindow:    peakWindow,
+		MaxProblems:          maxProblems,
+		Streaming:            streaming,
+		InputFormat:          inputFormat,
+		FFprobePath:          ffprobePath,
+		Headers:              httpHeaders,
+		UserAgent:            userAgent,
+		Retries:              retries,
+		RetryDelay:           retryDelay,
+		ProbeSize:            probeSize,
+		AnalyzeDuration:      analyzeDuration,
+		Stream:               streamSelection,
+		DetectorConfig:       detectorConfig(),
+	}
+
+	mediaAnalyzer := analyzer.New(options)
 	
 	// Use detailed analysis if problems are requested
 	if showProblems {
-		detailedResult, err := analyzer.AnalyzeWithDetails(input)
+		detailedResult, err := mediaAnalyzer.AnalyzeWithDetailsContext(cmd.Context(), input)
 		if err != nil {
-			return fmt.Errorf("failed to analyze media: %w", err)
+			if oneline {
+				failure := reporter.New(reporter.Options{})
+				failure.SetWriter(out)
+				failure.PrintOnelineFailure(input)
+			}
+			return analysisError(cmd, err)
+		}
+
+		if sinceFile != "" {
+			baseline, err := loadBaselineProblems(sinceFile)
+			if err != nil {
+				return err
+			}
+			detailedResult.Problems, detailedResult.Resolved = detector.DiffProblems(detailedResult.Problems, baseline)
 		}
+		if minSeverity != "" {
+			detailedResult.Problems = detector.FilterBySeverity(detailedResult.Problems, severityFloor)
+		}
+		detailedResult.RoundTimes(precision)
 
 		reporterOptions := reporter.Options{
 			Format:       getOutputFormat(),
 			Verbose:      verbose,
 			ShowProblems: showProblems,
+			Sections:     selectedSections,
+			ShowAllAudio: showAllAudio,
 		}
 
 		reporter := reporter.New(reporterOptions)
-		if err := reporter.PrintDetailed(detailedResult); err != nil {
+		reporter.SetWriter(out)
+		if oneline {
+			summary := detailedResult.Summary()
+			if err := reporter.PrintOneline(summary); err != nil {
+				return fmt.Errorf("failed to generate summary: %w", err)
+			}
+			notifyWebhook(detailedResult)
+			runOnComplete(detailedResult)
+			if failOn != "" {
+				return checkFailOn(cmd, detailedResult.Problems)
+			}
+			if summary.Errors() > 0 {
+				cmd.SilenceUsage = true
+				cmd.SilenceErrors = true
+				return errChecksFailed
+			}
+			return nil
+		}
+		if ndjsonSum {
+			if err := reporter.PrintSummaryLine(detailedResult.Summary()); err != nil {
+				return fmt.Errorf("failed to generate summary: %w", err)
+			}
+		} else if canonical {
+			if err := reporter.PrintCanonical(detailedResult); err != nil {
+				return fmt.Errorf("failed to generate report: %w", err)
+			}
+		} else if err := reporter.PrintDetailed(detailedResult); err != nil {
 			return fmt.Errorf("failed to generate report: %w", err)
 		}
+
+		notifyWebhook(detailedResult)
+		runOnComplete(detailedResult)
+		return checkFailOn(cmd, detailedResult.Problems)
 	} else {
 		// Use basic analysis without problem detection
-		result, err := analyzer.Analyze(input)
+		result, err := mediaAnalyzer.AnalyzeContext(cmd.Context(), input)
 		if err != nil {
-			return fmt.Errorf("failed to analyze media: %w", err)
+			return analysisError(cmd, err)
 		}
+		result.RoundTimes(precision)
 
 		reporterOptions := reporter.Options{
-			Format:  getOutputFormat(),
-			Verbose: verbose,
+			Format:       getOutputFormat(),
+			Verbose:      verbose,
+			Sections:     selectedSections,
+			ShowAllAudio: showAllAudio,
 		}
 
 		reporter := reporter.New(reporterOptions)
-		if err := reporter.Print(result); err != nil {
+		reporter.SetWriter(out)
+		if canonical {
+			err = reporter.PrintCanonical(result)
+		} else {
+			err = reporter.Print(result)
+		}
+		if err != nil {
 			return fmt.Errorf("failed to generate report: %w", err)
 		}
+
+		detailed := &analyzer.DetailedAnalysis{MediaInfo: result}
+		notifyWebhook(detailed)
+		runOnComplete(detailed)
 	}
 
 	return nil
 }
 
+// loadBaselineProblems reads problems from a previous run. Both the
+// problems.json written by export and a full JSON analysis are accepted.
+func loadBaselineProblems(path string) ([]detector.Problem, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+
+	var problems []detector.Problem
+	if err := json.Unmarshal(data, &problems); err == nil {
+		return problems, nil
+	}
+
+	var analysis analyzer.DetailedAnalysis
+	if err := json.Unmarshal(data, &analysis); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline %s: %w", path, err)
+	}
+	return analysis.Problems, nil
+}
+
 func getOutputFormat() reporter.Format {
 	switch strings.ToLower(output) {
 	case "json":
 		return reporter.FormatJSON
 	case "yaml":
 		return reporter.FormatYAML
+	case "github":
+		return reporter.FormatGitHub
 	case "text", "":
 		return reporter.FormatText
 	default:
@@ -1,16 +1,53 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/tomi/media-parser-cli/internal/detector"
+	"github.com/tomi/media-parser-cli/pkg/ffprobe"
 )
 
 var (
 	version = "0.1.0"
-	verbose bool
-	output  string
+	verbose  bool
+	output   string
+	cacheDir string
+	noCache  bool
+
+	severityOverrides []string
+	profileName       string
+	hashInput         bool
+	profileDetectors  bool
+	showCommand       bool
+	compatMatrixPath  string
+	slowWarning       float64
+	peakWindow        float64
+	precision         int
+	maxProblems       int
+	streaming         bool
+	inputFormat       string
+	keyframeDeviation float64
+	maxGOPDuration    float64
+	vfrThreshold      float64
+	ffprobePath       string
+	httpHeaders       []string
+	userAgent         string
+	retries           int
+	retryDelay        float64
+	probeSizeFlag     string
+	analyzeDurFlag    string
+	streamIndex       int
+	outFile           string
+	failOn            string
 )
 
 var rootCmd = &cobra.Command{
@@ -29,14 +66,292 @@ Perfect for debugging media issues and understanding media properties.`,
 	Version: version,
 }
 
+// Process exit codes
+const (
+	exitRuntimeError  = 1   // Bad arguments, or the input could not be analyzed
+	exitProblemsFound = 2   // Analysis succeeded but problems failed the checks
+	exitInterrupted   = 130 // Cancelled with Ctrl-C, as shells report SIGINT
+)
+
+// errChecksFailed makes the process exit with exitProblemsFound without
+// printing an error, for output modes that already reported the problems
+var errChecksFailed = errors.New("checks failed")
+
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	// Ctrl-C cancels the context every command runs under, which kills any
+	// running ffprobe; a second Ctrl-C terminates the process outright
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
+		if errors.Is(err, errChecksFailed) {
+			os.Exit(exitProblemsFound)
+		}
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		if errors.Is(err, ffprobe.ErrCancelled) {
+			os.Exit(exitInterrupted)
+		}
+		os.Exit(exitRuntimeError)
 	}
 }
 
 func init() {
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose output")
-	rootCmd.PersistentFlags().StringVarP(&output, "output", "o", "", "Output format (json, yaml, text)")
+	rootCmd.PersistentFlags().StringVarP(&output, "output", "o", "", "Output format (json, yaml, text, github)")
+	rootCmd.PersistentFlags().StringVar(&output, "format", "", "Alias for --output")
+	rootCmd.PersistentFlags().StringVar(&cacheDir, "cache-dir", "", "Cache detailed analysis results in this directory")
+	rootCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "Bypass the analysis cache")
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "Delivery profile to check against ("+strings.Join(detector.ProfileNames(), ", ")+"), or a profile .yaml/.json file")
+	rootCmd.PersistentFlags().BoolVar(&hashInput, "hash", false, "Record the absolute path and SHA-256 content hash of local files (hash only for stdin)")
+	rootCmd.PersistentFlags().BoolVar(&profileDetectors, "profile-detectors", false, "Record per-detector timings (ms) in the diagnostics output")
+	rootCmd.PersistentFlags().BoolVar(&showCommand, "show-command", false, "Print each ffprobe command to stderr before running it (credentials redacted)")
+	rootCmd.PersistentFlags().StringVar(&compatMatrixPath, "compat-matrix", "", "JSON/YAML file of custom codec/container compatibility rules")
+	rootCmd.PersistentFlags().Float64Var(&slowWarning, "slow-warning-threshold", 0.5, "Warn when a probe runs longer than this fraction of the timeout (0 disables)")
+	rootCmd.PersistentFlags().Float64Var(&peakWindow, "peak-window", 2, "Rolling window in seconds for peak bitrate")
+	rootCmd.PersistentFlags().IntVar(&precision, "precision", 3, "Decimal places for durations and timestamps in output (-1 keeps full precision)")
+	rootCmd.PersistentFlags().IntVar(&maxProblems, "max-problems", 0, "Stop recording problems after this many (0 is unlimited)")
+	rootCmd.PersistentFlags().BoolVar(&streaming, "streaming", false, "Apply low-latency streaming checks (always on for stream URLs)")
+	rootCmd.PersistentFlags().StringVar(&inputFormat, "input-format", "", "Force the ffprobe demuxer, e.g. mpegts, mp4, matroska, hls")
+	rootCmd.PersistentFlags().StringArrayVar(&httpHeaders, "header", nil, "Extra HTTP header for network streams, \"Name: value\" (repeatable)")
+	rootCmd.PersistentFlags().StringVar(&userAgent, "user-agent", "", "User-Agent for network streams")
+	rootCmd.PersistentFlags().IntVar(&retries, "retries", 2, "Retries for transient ffprobe failures on network streams")
+	rootCmd.PersistentFlags().Float64Var(&retryDelay, "retry-delay", 1, "Seconds before the first ffprobe retry, doubled after each")
+	rootCmd.PersistentFlags().StringVar(&probeSizeFlag, "probe-size", "", "Bytes ffprobe reads to detect streams, e.g. 50M or 5MiB (default: ffprobe's)")
+	rootCmd.PersistentFlags().StringVar(&analyzeDurFlag, "analyze-duration", "", "Input duration ffprobe analyzes to detect streams, e.g. 10s or 500ms (default: ffprobe's)")
+	rootCmd.PersistentFlags().IntVar(&streamIndex, "stream", -1, "Only analyze the stream with this index; packets and frames of other streams are not probed (-1 analyzes every stream)")
+	rootCmd.PersistentFlags().StringVar(&ffprobePath, "ffprobe-path", "", "Path to the ffprobe executable (default: ffprobe from PATH)")
+	rootCmd.PersistentFlags().Float64Var(&keyframeDeviation, "keyframe-deviation", 0.5, "Fractional deviation from the average keyframe interval that is flagged as irregular")
+	rootCmd.PersistentFlags().Float64Var(&maxGOPDuration, "max-gop-duration", 10, "GOP length in seconds that is flagged as too long")
+	rootCmd.PersistentFlags().Float64Var(&vfrThreshold, "vfr-threshold", 1, "Percent difference between the nominal and average frame rate that is flagged as variable frame rate")
+	rootCmd.PersistentFlags().StringArrayVar(&severityOverrides, "severity-override", nil, "Remap a problem severity as CODE=severity (repeatable)")
+}
+
+// selectedProfile resolves the --profile flag, returning nil when unset.
+// Values with a .yaml, .yml or .json extension are loaded as profile files.
+func selectedProfile() (*detector.Profile, error) {
+	if profileName == "" {
+		return nil, nil
+	}
+	switch strings.ToLower(filepath.Ext(profileName)) {
+	case ".yaml", ".yml", ".json":
+		return detector.LoadProfile(profileName)
+	}
+	return detector.LookupProfile(profileName)
+}
+
+// detectorConfig collects the detection threshold flags
+func detectorConfig() detector.DetectorConfig {
+	return detector.DetectorConfig{
+		IrregularKeyframeThreshold: keyframeDeviation,
+		MaxGOPDuration:             maxGOPDuration,
+		VFRThresholdPercent:        vfrThreshold,
+	}
+}
+
+// analysisError is the error a command returns when analysis fails. A
+// cancelled analysis is reported once by Execute, without the usage text.
+func analysisError(cmd *cobra.Command, err error) error {
+	if errors.Is(err, ffprobe.ErrCancelled) {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		return err
+	}
+	return fmt.Errorf("failed to analyze media: %w", err)
+}
+
+// failOnSeverity parses --fail-on. ok is false when the flag is unset.
+func failOnSeverity() (severity detector.Severity, ok bool, err error) {
+	if failOn == "" {
+		return detector.SeverityInfo, false, nil
+	}
+	severity, err = detector.ParseSeverity(failOn)
+	if err != nil {
+		return detector.SeverityInfo, false, fmt.Errorf("invalid --fail-on: %w", err)
+	}
+	return severity, true, nil
+}
+
+// checkFailOn returns errChecksFailed when --fail-on is set and any of the
+// reported problems is at or above it
+func checkFailOn(cmd *cobra.Command, problems []detector.Problem) error {
+	severity, ok, err := failOnSeverity()
+	if err != nil || !ok {
+		return err
+	}
+	if len(detector.FilterBySeverity(problems, severity)) == 0 {
+		return nil
+	}
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	return errChecksFailed
+}
+
+// openOutFile opens the --out-file destination for the report, or returns
+// stdout when it is unset. Callers close the file when it is not stdout.
+func openOutFile() (*os.File, error) {
+	if outFile == "" {
+		return os.Stdout, nil
+	}
+	file, err := os.Create(outFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output file: %w", err)
+	}
+	return file, nil
+}
+
+// inputArg returns the input named on the command line. Without one, media
+// piped to stdin is read, as with an explicit "-".
+func inputArg(args []string) (string, error) {
+	if len(args) > 0 {
+		return args[0], nil
+	}
+	if stat, err := os.Stdin.Stat(); err == nil && stat.Mode()&os.ModeCharDevice == 0 {
+		return ffprobe.StdinInput, nil
+	}
+	return "", fmt.Errorf("requires an input file, stream URL, or - to read stdin")
+}
+
+// byteSizeSuffixes are the multipliers accepted by --probe-size, matched
+// case-insensitively with an optional trailing "B"
+var byteSizeSuffixes = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"kib", 1 << 10}, {"mib", 1 << 20}, {"gib", 1 << 30},
+	{"k", 1000}, {"m", 1000 * 1000}, {"g", 1000 * 1000 * 1000},
+}
+
+// parseByteSize parses a byte count such as "5000000", "50M", "50MB" or
+// "5MiB"
+func parseByteSize(value string) (int64, error) {
+	s := strings.ToLower(strings.TrimSpace(value))
+	if !strings.HasSuffix(s, "ib") {
+		s = strings.TrimSuffix(s, "b")
+	}
+	multiplier := int64(1)
+	for _, unit := range byteSizeSuffixes {
+		if strings.HasSuffix(s, unit.suffix) {
+			s = strings.TrimSuffix(s, unit.suffix)
+			multiplier = unit.multiplier
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("expected a positive size such as 50M or 5MiB")
+	}
+	return int64(n * float64(multiplier)), nil
+}
+
+// parseSeconds parses a duration such as "10s", "500ms" or "1m30s"; a bare
+// number is taken as seconds
+func parseSeconds(value string) (float64, error) {
+	s := strings.TrimSpace(value)
+	if seconds, err := strconv.ParseFloat(s, 64); err == nil && seconds > 0 {
+		return seconds, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("expected a positive duration such as 10s or 500ms")
+	}
+	return d.Seconds(), nil
+}
+
+// probeLimits parses --probe-size and --analyze-duration. Unset flags are
+// returned as 0, which keeps ffprobe's defaults.
+func probeLimits() (probeSize int64, analyzeDuration float64, err error) {
+	if probeSizeFlag != "" {
+		if probeSize, err = parseByteSize(probeSizeFlag); err != nil {
+			return 0, 0, fmt.Errorf("invalid --probe-size %q: %w", probeSizeFlag, err)
+		}
+	}
+	if analyzeDurFlag != "" {
+		if analyzeDuration, err = parseSeconds(analyzeDurFlag); err != nil {
+			return 0, 0, fmt.Errorf("invalid --analyze-duration %q: %w", analyzeDurFlag, err)
+		}
+	}
+	return probeSize, analyzeDuration, nil
+}
+
+// selectedStream returns the --stream index, or nil when every stream is
+// analyzed
+func selectedStream() (*int, error) {
+	if streamIndex == -1 {
+		return nil, nil
+	}
+	if streamIndex < 0 {
+		return nil, fmt.Errorf("invalid --stream %d: stream indexes start at 0", streamIndex)
+	}
+	index := streamIndex
+	return &index, nil
+}
+
+// validateHeaders checks that each --header is a single "Name: value" line
+func validateHeaders() error {
+	for _, header := range httpHeaders {
+		name, _, found := strings.Cut(header, ":")
+		if !found || strings.TrimSpace(name) == "" || strings.ContainsAny(header, "\r\n") {
+			return fmt.Errorf("invalid --header %q: expected \"Name: value\"", header)
+		}
+	}
+	return nil
+}
+
+// validateInputFormat checks the --input-format hint against the allowlist
+// and, when ffprobe can list them, its supported demuxers
+func validateInputFormat() error {
+	if inputFormat == "" {
+		return nil
+	}
+	if _, ok := ffprobe.InputFormats[inputFormat]; !ok {
+		return fmt.Errorf("unsupported --input-format %q (supported: %s)", inputFormat, strings.Join(ffprobe.InputFormatNames(), ", "))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if demuxers, err := ffprobe.NewWithBinary(ffprobePath).Demuxers(ctx); err == nil && !demuxers[inputFormat] {
+		return fmt.Errorf("--input-format %q is not supported by the installed ffprobe", inputFormat)
+	}
+	return nil
+}
+
+// loadCompatMatrix resolves the --compat-matrix flag, returning nil when unset
+func loadCompatMatrix() (*detector.CompatMatrix, error) {
+	if compatMatrixPath == "" {
+		return nil, nil
+	}
+	return detector.LoadCompatMatrix(compatMatrixPath)
+}
+
+// parseSeverityOverrides converts CODE=severity flag values into a map
+func parseSeverityOverrides() (map[string]detector.Severity, error) {
+	if len(severityOverrides) == 0 {
+		return nil, nil
+	}
+
+	overrides := make(map[string]detector.Severity, len(severityOverrides))
+	for _, value := range severityOverrides {
+		code, name, ok := strings.Cut(value, "=")
+		if !ok || strings.TrimSpace(code) == "" {
+			return nil, fmt.Errorf("invalid --severity-override %q (expected CODE=severity)", value)
+		}
+		severity, err := detector.ParseSeverity(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --severity-override %q: %w", value, err)
+		}
+		overrides[strings.ToUpper(strings.TrimSpace(code))] = severity
+	}
+	return overrides, nil
+}
+
+// analysisCacheDir returns the cache directory to use, or "" when caching is off
+func analysisCacheDir() string {
+	if noCache {
+		return ""
+	}
+	return cacheDir
 }
\ No newline at end of file
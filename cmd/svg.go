@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/tomi/media-parser-cli/internal/detector"
+)
+
+// Bitrate chart geometry in SVG user units
+const (
+	svgWidth        = 900
+	svgHeight       = 420
+	svgMarginLeft   = 70
+	svgMarginRight  = 120
+	svgMarginTop    = 40
+	svgMarginBottom = 50
+	svgTicks        = 5
+)
+
+// bitrateSeries lists the timeline types in drawing order with their colors
+var bitrateSeries = []struct {
+	Type  string
+	Color string
+}{
+	{"total", "#444444"},
+	{"video", "#1f77b4"},
+	{"audio", "#ff7f0e"},
+}
+
+// exportBitrateSVG writes the bitrate timeline as a standalone SVG line chart
+func exportBitrateSVG(filename, title string, timeline []detector.BitratePoint) error {
+	return os.WriteFile(filename, []byte(renderBitrateSVG(title, timeline)), 0644)
+}
+
+// renderBitrateSVG draws one line per timeline type (total, video, audio)
+// present in timeline, with time in seconds on the x axis and Mbps on the y
+// axis
+func renderBitrateSVG(title string, timeline []detector.BitratePoint) string {
+	series := make(map[string][]detector.BitratePoint)
+	var maxTime, maxBitrate float64
+	for _, point := range timeline {
+		series[point.Type] = append(series[point.Type], point)
+		maxTime = math.Max(maxTime, point.Time)
+		maxBitrate = math.Max(maxBitrate, point.Bitrate)
+	}
+	if maxTime <= 0 {
+		maxTime = 1
+	}
+	maxMbps := niceCeil(maxBitrate / 1000000)
+
+	plotWidth := float64(svgWidth - svgMarginLeft - svgMarginRight)
+	plotHeight := float64(svgHeight - svgMarginTop - svgMarginBottom)
+	x := func(t float64) float64 { return svgMarginLeft + t/maxTime*plotWidth }
+	y := func(bps float64) float64 { return svgMarginTop + plotHeight - bps/1000000/maxMbps*plotHeight }
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\" font-family=\"sans-serif\" font-size=\"12\">\n", svgWidth, svgHeight, svgWidth, svgHeight)
+	fmt.Fprintf(&b, "  <rect width=\"%d\" height=\"%d\" fill=\"white\"/>\n", svgWidth, svgHeight)
+	fmt.Fprintf(&b, "  <text x=\"%d\" y=\"24\" text-anchor=\"middle\" font-size=\"16\">%s</text>\n", svgWidth/2, html.EscapeString(title))
+
+	// Grid lines and tick labels
+	for i := 0; i <= svgTicks; i++ {
+		mbps := maxMbps * float64(i) / svgTicks
+		yPos := y(mbps * 1000000)
+		fmt.Fprintf(&b, "  <line x1=\"%d\" y1=\"%.1f\" x2=\"%.1f\" y2=\"%.1f\" stroke=\"#e0e0e0\"/>\n", svgMarginLeft, yPos, svgMarginLeft+plotWidth, yPos)
+		fmt.Fprintf(&b, "  <text x=\"%d\" y=\"%.1f\" text-anchor=\"end\">%s</text>\n", svgMarginLeft-6, yPos+4, formatTick(mbps))
+
+		seconds := maxTime * float64(i) / svgTicks
+		xPos := x(seconds)
+		fmt.Fprintf(&b, "  <text x=\"%.1f\" y=\"%.1f\" text-anchor=\"middle\">%s</text>\n", xPos, svgMarginTop+plotHeight+18, formatTick(seconds))
+	}
+
+	// Axes and labels
+	fmt.Fprintf(&b, "  <line x1=\"%d\" y1=\"%d\" x2=\"%d\" y2=\"%.1f\" stroke=\"black\"/>\n", svgMarginLeft, svgMarginTop, svgMarginLeft, svgMarginTop+plotHeight)
+	fmt.Fprintf(&b, "  <line x1=\"%d\" y1=\"%.1f\" x2=\"%.1f\" y2=\"%.1f\" stroke=\"black\"/>\n", svgMarginLeft, svgMarginTop+plotHeight, svgMarginLeft+plotWidth, svgMarginTop+plotHeight)
+	fmt.Fprintf(&b, "  <text x=\"%.1f\" y=\"%d\" text-anchor=\"middle\">Time (s)</text>\n", svgMarginLeft+plotWidth/2, svgHeight-10)
+	fmt.Fprintf(&b, "  <text x=\"18\" y=\"%.1f\" text-anchor=\"middle\" transform=\"rotate(-90 18 %.1f)\">Bitrate (Mbps)</text>\n", svgMarginTop+plotHeight/2, svgMarginTop+plotHeight/2)
+
+	// One polyline per series, with a legend entry
+	legendY := svgMarginTop + 10
+	for _, s := range bitrateSeries {
+		points := series[s.Type]
+		if len(points) == 0 {
+			continue
+		}
+		coords := make([]string, len(points))
+		for i, point := range points {
+			coords[i] = fmt.Sprintf("%.1f,%.1f", x(point.Time), y(point.Bitrate))
+		}
+		fmt.Fprintf(&b, "  <polyline fill=\"none\" stroke=\"%s\" stroke-width=\"1.5\" points=\"%s\"/>\n", s.Color, strings.Join(coords, " "))
+
+		legendX := svgWidth - svgMarginRight + 15
+		fmt.Fprintf(&b, "  <line x1=\"%d\" y1=\"%d\" x2=\"%d\" y2=\"%d\" stroke=\"%s\" stroke-width=\"3\"/>\n", legendX, legendY, legendX+20, legendY, s.Color)
+		fmt.Fprintf(&b, "  <text x=\"%d\" y=\"%d\">%s</text>\n", legendX+26, legendY+4, s.Type)
+		legendY += 20
+	}
+
+	b.WriteString("</svg>\n")
+	return b.String()
+}
+
+// niceCeil rounds v up to 1, 2 or 5 times a power of ten so axis ticks land
+// on readable values
+func niceCeil(v float64) float64 {
+	if v <= 0 {
+		return 1
+	}
+	magnitude := math.Pow(10, math.Floor(math.Log10(v)))
+	for _, step := range []float64{1, 2, 5, 10} {
+		if v <= step*magnitude {
+			return step * magnitude
+		}
+	}
+	return 10 * magnitude
+}
+
+// formatTick prints an axis value without trailing zeros
+func formatTick(v float64) string {
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", v), "0"), ".")
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/tomi/media-parser-cli/internal/analyzer"
+	"github.com/tomi/media-parser-cli/internal/reporter"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate [file or stream URL] --profile [name or file]",
+	Short: "Check a file against a delivery profile and exit non-zero on violations",
+	Long: `Validate analyzes a media file and checks it against a delivery profile:
+one of the built-in profiles, or a YAML/JSON profile file declaring allowed
+codecs, maximum resolution, maximum or target bitrate, allowed frame rates
+and audio requirements.
+
+Every violation is reported as a problem. The command exits with status 2
+when there is at least one.
+
+Example profile (web1080.yaml):
+  name: web1080
+  video_codecs: [h264]
+  max_width: 1920
+  max_height: 1080
+  max_video_bitrate: 8000000
+  max_frame_rate: 30
+  audio_codecs: [aac]
+  audio_channels: 2
+  audio_sample_rate: 48000
+
+Examples:
+  media-parser-cli validate video.mp4 --profile web1080.yaml
+  media-parser-cli validate video.mp4 --profile broadcast -o json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runValidate,
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+
+	validateCmd.Flags().StringVar(&outFile, "out-file", "", "Write the report to this file instead of stdout")
+	validateCmd.Flags().IntVar(&timeout, "timeout", 30, "Analysis timeout in seconds")
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	input := args[0]
+
+	if profileName == "" {
+		return fmt.Errorf("validate needs a --profile to check against")
+	}
+	profile, err := selectedProfile()
+	if err != nil {
+		return err
+	}
+
+	if err := ensureFFprobe(); err != nil {
+		return err
+	}
+
+	overrides, err := parseSeverityOverrides()
+	if err != nil {
+		return err
+	}
+	compatMatrix, err := loadCompatMatrix()
+	if err != nil {
+		return err
+	}
+	if err := validateInputFormat(); err != nil {
+		return err
+	}
+	if err := validateHeaders(); err != nil {
+		return err
+	}
+	probeSize, analyzeDuration, err := probeLimits()
+	if err != nil {
+		return err
+	}
+	streamSelection, err := selectedStream()
+	if err != nil {
+		return err
+	}
+
+	// Packets give a measured bitrate when the container declares none.
+	// --max-problems is not applied: earlier problems could use up the cap
+	// and hide violations, turning a failing input into a PASS.
+	options := analyzer.Options{
+		Timeout:              timeout,
+		ShowVideo:            true,
+		ShowAudio:            true,
+		ShowFormat:           true,
+		Verbose:              verbose,
+		AnalyzePackets:       true,
+		AnalyzeFrames:        false,
+		MaxPackets:           1000,
+		CacheDir:             analysisCacheDir(),
+		SeverityOverrides:    overrides,
+		Profile:              profile,
+		Hash:                 hashInput,
+		ProfileDetectors:     profileDetectors,
+		ShowCommand:          showCommand,
+		CompatMatrix:         compatMatrix,
+		SlowWarningThreshold: slowWarning,
+		PeakBitrateWindow:    peakWindow,
+		Streaming:            streaming,
+		InputFormat:          inputFormat,
+		FFprobePath:          ffprobePath,
+		Headers:              httpHeaders,
+		UserAgent:            userAgent,
+		Retries:              retries,
+		RetryDelay:           retryDelay,
+		ProbeSize:            probeSize,
+		AnalyzeDuration:      analyzeDuration,
+		Stream:               streamSelection,
+		DetectorConfig:       detectorConfig(),
+	}
+
+	result, err := analyzer.New(options).AnalyzeWithDetailsContext(cmd.Context(), input)
+	if err != nil {
+		return analysisError(cmd, err)
+	}
+	result.RoundTimes(precision)
+	report := reporter.NewValidationReport(result, profile)
+
+	out, err := openOutFile()
+	if err != nil {
+		return err
+	}
+	if out != os.Stdout {
+		defer out.Close()
+	}
+
+	r := reporter.New(reporter.Options{Format: getOutputFormat(), Verbose: verbose})
+	r.SetWriter(out)
+	if err := r.PrintValidation(report); err != nil {
+		return fmt.Errorf("failed to generate report: %w", err)
+	}
+
+	if !report.Passed {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		return errChecksFailed
+	}
+	return nil
+}
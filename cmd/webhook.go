@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/tomi/media-parser-cli/internal/analyzer"
+	"github.com/tomi/media-parser-cli/internal/webhook"
+)
+
+var (
+	webhookURL     string
+	webhookTimeout int
+	webhookAuth    string
+	webhookRetries int
+	webhookFull    bool
+)
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&webhookURL, "webhook", "", "POST the analysis summary as JSON to this URL")
+	rootCmd.PersistentFlags().IntVar(&webhookTimeout, "webhook-timeout", 10, "Webhook request timeout in seconds")
+	rootCmd.PersistentFlags().StringVar(&webhookAuth, "webhook-auth", "", "Authorization header value for the webhook (e.g. \"Bearer <token>\")")
+	rootCmd.PersistentFlags().IntVar(&webhookRetries, "webhook-retries", 2, "Retries for transient webhook failures")
+	rootCmd.PersistentFlags().BoolVar(&webhookFull, "webhook-full", false, "Send the full analysis instead of the summary")
+}
+
+// notifyWebhook delivers the analysis to the configured webhook, if any.
+// Delivery problems are reported on stderr and never fail the command.
+func notifyWebhook(result *analyzer.DetailedAnalysis) {
+	if webhookURL == "" {
+		return
+	}
+
+	var payload interface{} = result.Summary()
+	if webhookFull {
+		payload = result
+	}
+
+	options := webhook.Options{
+		URL:        webhookURL,
+		Timeout:    time.Duration(webhookTimeout) * time.Second,
+		AuthHeader: webhookAuth,
+		Retries:    webhookRetries,
+	}
+
+	status, err := webhook.Send(options, payload)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Webhook delivery failed after %d attempt(s): %v\n", status.Attempts, err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Webhook delivered (HTTP %d)\n", status.StatusCode)
+}
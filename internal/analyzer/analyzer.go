@@ -2,38 +2,116 @@ package analyzer
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/tomi/media-parser-cli/internal/cache"
 	"github.com/tomi/media-parser-cli/internal/detector"
 	"github.com/tomi/media-parser-cli/pkg/ffprobe"
 )
 
 type Options struct {
-	Timeout        int
-	ShowVideo      bool
-	ShowAudio      bool
-	ShowFormat     bool
-	ShowStreams    bool
-	Verbose        bool
-	AnalyzePackets bool
-	AnalyzeFrames  bool
-	MaxPackets     int
-	MaxFrames      int
+	Timeout              int
+	ShowVideo            bool
+	ShowAudio            bool
+	ShowFormat           bool
+	ShowStreams          bool
+	Verbose              bool
+	AnalyzePackets       bool
+	AnalyzeFrames        bool
+	MaxPackets           int
+	MaxFrames            int
+	CacheDir             string                       // Directory for cached detailed results; empty disables caching
+	SeverityOverrides    map[string]detector.Severity // Remaps problem severities by problem code
+	Profile              *detector.Profile            // Delivery profile to enforce; nil disables profile checks
+	Hash                 bool                         // Record the absolute path and SHA-256 of local files; stdin gets the hash only
+	Fingerprint          bool                         // Record a parameter fingerprint (codec, resolution, duration, stream layout)
+	ProfileDetectors     bool                         // Record per-detector timings in Diagnostics
+	ShowCommand          bool                         // Print each ffprobe command line to stderr before running it
+	CompatMatrix         *detector.CompatMatrix       // Custom compatibility rules; nil uses only the built-ins
+	PeakBitrateWindow    float64                      // Rolling window in seconds for PeakBitrate; 0 uses 2s
+	MaxProblems          int                          // Cap on recorded problems; 0 is unlimited
+	Streaming            bool                         // Apply low-latency streaming checks (implied for URL inputs)
+	InputFormat          string                       // Demuxer hint passed to ffprobe as -f; empty auto-detects
+	FFprobePath          string                       // ffprobe executable to run; empty uses ffprobe from PATH
+	Headers              []string                     // Extra HTTP headers ("Name: value") for HTTP(S) inputs
+	UserAgent            string                       // User-Agent for HTTP(S) inputs; empty keeps ffprobe's default
+	Retries              int                          // Extra ffprobe attempts after a transient failure of a network input
+	RetryDelay           float64                      // Seconds before the first retry, doubled after each; 0 uses 1s
+	ProbeSize            int64                        // Bytes ffprobe reads to detect streams (-probesize); 0 keeps the default
+	AnalyzeDuration      float64                      // Seconds ffprobe analyzes to detect streams (-analyzeduration); 0 keeps the default
+	Stream               *int                         // Only analyze the stream with this index; nil analyzes every stream
+	SlowWarningThreshold float64                      // Warn on stderr when a probe exceeds this fraction of Timeout; 0 disables
+	DetectorConfig       detector.DetectorConfig      // Detection thresholds; zero fields use the defaults
 }
 
+// Analyzer is safe for concurrent use: each Analyze or AnalyzeWithDetails
+// call runs its own ffprobe processes under its own context and builds its
+// own detector and results, and the analyzer itself is never modified after
+// New. Callers must not modify maps or pointers passed in Options (such as
+// SeverityOverrides, Profile or CompatMatrix) while calls are in flight.
 type Analyzer struct {
 	options Options
 	ffprobe *ffprobe.FFProbe
 }
 
 type MediaInfo struct {
-	Input       string         `json:"input"`
-	Format      *FormatInfo    `json:"format,omitempty"`
-	VideoStream *VideoInfo     `json:"video,omitempty"`
-	AudioStream *AudioInfo     `json:"audio,omitempty"`
-	Streams     []StreamInfo   `json:"streams,omitempty"`
-	AnalyzedAt  time.Time      `json:"analyzed_at"`
+	Input        string           `json:"input"`
+	Format       *FormatInfo      `json:"format,omitempty"`
+	VideoStream  *VideoInfo       `json:"video,omitempty"`
+	VideoStreams []VideoInfo      `json:"video_streams,omitempty"` // Every video stream; VideoStream is the first
+	AudioStream  *AudioInfo       `json:"audio,omitempty"`
+	AudioStreams []AudioInfo      `json:"audio_streams,omitempty"` // Every audio stream; AudioStream is the first
+	CoverArt     *VideoInfo       `json:"cover_art,omitempty"`     // Attached picture, e.g. album art
+	DataStreams  []DataStreamInfo `json:"data_streams,omitempty"`  // Data streams such as SCTE-35 or KLV
+	FormatHint   *FormatHint      `json:"format_hint,omitempty"`
+	Renditions   []RenditionInfo  `json:"renditions,omitempty"` // Variants of an HLS/DASH manifest
+
+	Streams       []StreamInfo   `json:"streams,omitempty"`
+	StreamCounts  map[string]int `json:"stream_counts,omitempty"` // Number of streams per codec type; cover art counts as "cover_art"
+	StartTimecode string         `json:"start_timecode,omitempty"`
+	AbsolutePath  string         `json:"absolute_path,omitempty"`
+	ContentHash   string         `json:"content_hash,omitempty"` // SHA-256 of the file contents
+	Fingerprint   string         `json:"fingerprint,omitempty"`  // SHA-256 of the technical parameters, see Fingerprint
+	AnalyzedAt    time.Time      `json:"analyzed_at"`
+
+	FFprobeLog []FFprobeLogMessage `json:"ffprobe_log,omitempty"` // ffprobe's own warnings and errors, captured in verbose mode
+
+	attachedPics map[int]bool // Indexes of cover art streams, whose frames are kept out of video checks
+}
+
+// RenditionInfo describes one variant of an adaptive (HLS/DASH) stream
+type RenditionInfo struct {
+	ProgramID  int    `json:"program_id"`
+	Bitrate    int64  `json:"bitrate,omitempty"` // Declared variant bitrate
+	VideoCodec string `json:"video_codec,omitempty"`
+	Width      int    `json:"width,omitempty"`
+	Height     int    `json:"height,omitempty"`
+	AudioCodec string `json:"audio_codec,omitempty"`
+}
+
+// FormatHint records an --input-format hint and whether the demuxed format
+// matched it
+type FormatHint struct {
+	Format  string `json:"format"`
+	Matched bool   `json:"matched"`
+}
+
+// DataStreamInfo describes a data stream (codec_type "data")
+type DataStreamInfo struct {
+	Index          int    `json:"index"`
+	Codec          string `json:"codec,omitempty"`
+	CodecTagString string `json:"codec_tag_string,omitempty"`
+	Type           string `json:"type"` // Best-effort label: SCTE-35, KLV, timed_id3 or the codec name
 }
 
 type FormatInfo struct {
@@ -43,86 +121,217 @@ type FormatInfo struct {
 	Size           int64             `json:"size"`
 	Bitrate        int64             `json:"bitrate"`
 	ProbeScore     int               `json:"probe_score"`
+	DurationSource string            `json:"duration_source,omitempty"` // "container" or "stream"
+	Fragmented     bool              `json:"fragmented,omitempty"`      // Fragmented MP4 (DASH/CMAF brands)
 	Tags           map[string]string `json:"tags,omitempty"`
 }
 
+// Duration sources reported in FormatInfo.DurationSource
+const (
+	DurationSourceContainer = "container"
+	DurationSourceStream    = "stream"
+)
+
 type VideoInfo struct {
-	Index          int     `json:"index"`
-	Codec          string  `json:"codec"`
-	CodecLongName  string  `json:"codec_long_name"`
-	Profile        string  `json:"profile,omitempty"`
-	Width          int     `json:"width"`
-	Height         int     `json:"height"`
-	AspectRatio    string  `json:"aspect_ratio"`
-	PixelFormat    string  `json:"pixel_format"`
-	FrameRate      string  `json:"frame_rate"`
-	AvgFrameRate   string  `json:"avg_frame_rate"`
-	Bitrate        int64   `json:"bitrate,omitempty"`
-	Duration       float64 `json:"duration,omitempty"`
-	FrameCount     int64   `json:"frame_count,omitempty"`
-	Level          int     `json:"level,omitempty"`
-	ColorSpace     string  `json:"color_space,omitempty"`
-	ColorPrimaries string  `json:"color_primaries,omitempty"`
-	ColorTransfer  string  `json:"color_transfer,omitempty"`
-	HasBFrames     int     `json:"has_b_frames,omitempty"`
+	Index             int                   `json:"index"`
+	Codec             string                `json:"codec"`
+	CodecLongName     string                `json:"codec_long_name"`
+	CodecTagString    string                `json:"codec_tag_string,omitempty"`
+	Profile           string                `json:"profile,omitempty"`
+	Width             int                   `json:"width"`
+	Height            int                   `json:"height"`
+	AspectRatio       string                `json:"aspect_ratio"`
+	SampleAspectRatio string                `json:"sample_aspect_ratio,omitempty"`
+	TimeBase          string                `json:"time_base,omitempty"`
+	PixelFormat       string                `json:"pixel_format"`
+	FrameRate         string                `json:"frame_rate"`
+	AvgFrameRate      string                `json:"avg_frame_rate"`
+	FrameRateValue    float64               `json:"frame_rate_value,omitempty"`     // FrameRate in fps; 0 when undefined
+	AvgFrameRateValue float64               `json:"avg_frame_rate_value,omitempty"` // AvgFrameRate in fps; 0 when undefined
+	Bitrate           int64                 `json:"bitrate,omitempty"`
+	Duration          float64               `json:"duration,omitempty"`
+	StartTime         float64               `json:"start_time,omitempty"`
+	FrameCount        int64                 `json:"frame_count,omitempty"`
+	Level             int                   `json:"level,omitempty"`
+	ColorSpace        string                `json:"color_space,omitempty"` // Matrix coefficients, e.g. bt709
+	ColorPrimaries    string                `json:"color_primaries,omitempty"`
+	ColorTransfer     string                `json:"color_transfer,omitempty"`
+	HasBFrames        int                   `json:"has_b_frames,omitempty"`
+	Encoder           string                `json:"encoder,omitempty"`
+	MeasuredBitrate   int64                 `json:"measured_bitrate,omitempty"` // Average measured from packets, when analyzed
+	HDR               *detector.HDRMetadata `json:"hdr,omitempty"`              // HDR10 static metadata, when present
+	Stereo3D          string                `json:"stereo_3d,omitempty"`        // Stereoscopic 3D layout, e.g. "side by side"
+	Default           bool                  `json:"default,omitempty"`          // Disposition: played unless the user picks another stream
+	Forced            bool                  `json:"forced,omitempty"`
+	AttachedPic       bool                  `json:"attached_pic,omitempty"` // Disposition: cover art, not video
 }
 
 type AudioInfo struct {
-	Index         int     `json:"index"`
-	Codec         string  `json:"codec"`
-	CodecLongName string  `json:"codec_long_name"`
-	Profile       string  `json:"profile,omitempty"`
-	Channels      int     `json:"channels"`
-	ChannelLayout string  `json:"channel_layout"`
-	SampleRate    int     `json:"sample_rate"`
-	SampleFormat  string  `json:"sample_format"`
-	Bitrate       int64   `json:"bitrate,omitempty"`
-	Duration      float64 `json:"duration,omitempty"`
+	Index                   int               `json:"index"`
+	Codec                   string            `json:"codec"`
+	CodecLongName           string            `json:"codec_long_name"`
+	Profile                 string            `json:"profile,omitempty"`
+	Channels                int               `json:"channels"`
+	ChannelLayout           string            `json:"channel_layout"`
+	ChannelLayoutNormalized string            `json:"channel_layout_normalized,omitempty"` // Canonical layout, stable across ffmpeg versions
+	SampleRate              int               `json:"sample_rate"`
+	SampleFormat            string            `json:"sample_format"`
+	Bitrate                 int64             `json:"bitrate,omitempty"`
+	Duration                float64           `json:"duration,omitempty"`
+	StartTime               float64           `json:"start_time,omitempty"`
+	TimeBase                string            `json:"time_base,omitempty"`
+	InitialPadding          int               `json:"initial_padding,omitempty"` // Encoder delay in samples
+	Encoder                 string            `json:"encoder,omitempty"`
+	Language                string            `json:"language,omitempty"`
+	Tags                    map[string]string `json:"tags,omitempty"`
+	Default                 bool              `json:"default,omitempty"` // Disposition: played unless the user picks another track
+	Forced                  bool              `json:"forced,omitempty"`  // Disposition: must be played, e.g. foreign-language parts
 }
 
 type StreamInfo struct {
-	Index     int               `json:"index"`
-	Type      string            `json:"type"`
-	Codec     string            `json:"codec"`
-	CodecType string            `json:"codec_type"`
-	Tags      map[string]string `json:"tags,omitempty"`
+	Index       int               `json:"index"`
+	Type        string            `json:"type"`
+	Codec       string            `json:"codec"`
+	CodecType   string            `json:"codec_type"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Default     bool              `json:"default,omitempty"`
+	Forced      bool              `json:"forced,omitempty"`
+	AttachedPic bool              `json:"attached_pic,omitempty"`
 }
 
 func New(options Options) *Analyzer {
+	probe := ffprobe.NewWithBinary(options.FFprobePath)
+	if options.ShowCommand {
+		probe.SetCommandWriter(os.Stderr)
+	}
+	probe.SetInputFormat(options.InputFormat)
+	probe.SetHeaders(options.Headers)
+	probe.SetUserAgent(options.UserAgent)
+	probe.SetRetries(options.Retries, time.Duration(options.RetryDelay*float64(time.Second)))
+	probe.SetCaptureLog(options.Verbose)
+	probe.SetProbeSize(options.ProbeSize)
+	probe.SetAnalyzeDuration(time.Duration(options.AnalyzeDuration * float64(time.Second)))
+	if options.Stream != nil {
+		probe.SetSelectStreams(strconv.Itoa(*options.Stream))
+	}
 	return &Analyzer{
 		options: options,
-		ffprobe: ffprobe.New(),
+		ffprobe: probe,
 	}
 }
 
 func (a *Analyzer) Analyze(input string) (*MediaInfo, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(a.options.Timeout)*time.Second)
+	return a.AnalyzeContext(context.Background(), input)
+}
+
+// AnalyzeContext is Analyze with a parent context; cancelling it stops
+// ffprobe and returns ffprobe.ErrCancelled
+func (a *Analyzer) AnalyzeContext(ctx context.Context, input string) (*MediaInfo, error) {
+	if input == ffprobe.StdinInput && a.options.Hash {
+		return a.analyzeStdinHashed(ctx)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(a.options.Timeout)*time.Second)
 	defer cancel()
 
+	stopSlowWarning := a.warnIfSlow("stream")
 	probeData, err := a.ffprobe.Probe(ctx, input)
+	stopSlowWarning()
+	if errors.Is(err, ffprobe.ErrCancelled) {
+		return nil, err
+	}
 	if err != nil {
 		return nil, fmt.Errorf("ffprobe failed: %w", err)
 	}
+	if a.options.Stream != nil {
+		if probeData.Streams, err = selectStream(probeData.Streams, *a.options.Stream); err != nil {
+			return nil, err
+		}
+	}
 
 	info := &MediaInfo{
 		Input:      input,
 		AnalyzedAt: time.Now(),
+		FFprobeLog: mergeFFprobeLog(nil, probeData.Warnings),
 	}
 
 	if a.options.ShowFormat && probeData.Format != nil {
 		info.Format = a.extractFormatInfo(probeData.Format)
+		a.resolveDuration(info.Format, probeData.Streams)
+	}
+
+	info.StartTimecode = extractTimecode(probeData)
+
+	if a.options.InputFormat != "" {
+		info.FormatHint = &FormatHint{Format: a.options.InputFormat}
+		if probeData.Format != nil {
+			for _, name := range strings.Split(probeData.Format.FormatName, ",") {
+				if name == a.options.InputFormat {
+					info.FormatHint.Matched = true
+				}
+			}
+		}
+		if a.options.Verbose {
+			fmt.Fprintf(os.Stderr, "Input format hint applied: %s (matched: %t)\n", info.FormatHint.Format, info.FormatHint.Matched)
+		}
+	}
+
+	// Piped input has no path; it is buffered and its copy hashed instead
+	if a.options.Hash && !isRemoteInput(input) && input != ffprobe.StdinInput {
+		if info.AbsolutePath, err = filepath.Abs(input); err != nil {
+			return nil, fmt.Errorf("failed to resolve path: %w", err)
+		}
+		if info.ContentHash, err = hashFile(input); err != nil {
+			return nil, fmt.Errorf("failed to hash input: %w", err)
+		}
+	}
+
+	if a.options.Fingerprint {
+		info.Fingerprint = Fingerprint(probeData)
 	}
 
+	if probeData.Format != nil && detector.IsAdaptiveFormat(probeData.Format.FormatName) {
+		info.Renditions = extractRenditions(probeData.Programs)
+	}
+
+	info.StreamCounts = make(map[string]int)
+
 	for _, stream := range probeData.Streams {
+		// Attached pictures are album art, not video; keep them out of the
+		// video stream selection and counts
+		if stream.CodecType == "video" && stream.Disposition.AttachedPic == 1 {
+			info.StreamCounts["cover_art"]++
+			if info.attachedPics == nil {
+				info.attachedPics = make(map[int]bool)
+			}
+			info.attachedPics[stream.Index] = true
+			if a.options.ShowVideo && info.CoverArt == nil {
+				info.CoverArt = a.extractVideoInfo(&stream)
+			}
+			if a.options.ShowStreams {
+				info.Streams = append(info.Streams, a.extractStreamInfo(&stream))
+			}
+			continue
+		}
+
+		info.StreamCounts[stream.CodecType]++
+
 		switch stream.CodecType {
 		case "video":
-			if a.options.ShowVideo && info.VideoStream == nil {
-				info.VideoStream = a.extractVideoInfo(&stream)
+			if a.options.ShowVideo {
+				info.VideoStreams = append(info.VideoStreams, *a.extractVideoInfo(&stream))
 			}
 		case "audio":
-			if a.options.ShowAudio && info.AudioStream == nil {
-				info.AudioStream = a.extractAudioInfo(&stream)
+			if a.options.ShowAudio {
+				info.AudioStreams = append(info.AudioStreams, *a.extractAudioInfo(&stream))
 			}
+		case "data":
+			info.DataStreams = append(info.DataStreams, DataStreamInfo{
+				Index:          stream.Index,
+				Codec:          stream.CodecName,
+				CodecTagString: stream.CodecTagString,
+				Type:           detector.DataStreamType(stream.CodecName, stream.CodecTagString),
+			})
 		}
 
 		if a.options.ShowStreams {
@@ -130,9 +339,82 @@ func (a *Analyzer) Analyze(input string) (*MediaInfo, error) {
 		}
 	}
 
+	if len(info.VideoStreams) > 0 {
+		info.VideoStream = &info.VideoStreams[0]
+	}
+	if len(info.AudioStreams) > 0 {
+		info.AudioStream = &info.AudioStreams[0]
+	}
+
+	// Muxers often record the encoder only at the container level
+	if probeData.Format != nil {
+		if encoder := probeData.Format.Tags["encoder"]; encoder != "" {
+			if info.VideoStream != nil && info.VideoStream.Encoder == "" {
+				info.VideoStream.Encoder = encoder
+			}
+			for i := range info.AudioStreams {
+				if info.AudioStreams[i].Encoder == "" {
+					info.AudioStreams[i].Encoder = encoder
+				}
+			}
+		}
+	}
+
 	return info, nil
 }
 
+// extractRenditions summarizes each program of an adaptive stream: its
+// declared variant bitrate and the codec of its first video and audio stream
+func extractRenditions(programs []ffprobe.Program) []RenditionInfo {
+	renditions := make([]RenditionInfo, 0, len(programs))
+	for _, program := range programs {
+		rendition := RenditionInfo{ProgramID: program.ProgramID}
+		if bitrate, err := strconv.ParseInt(program.Tags["variant_bitrate"], 10, 64); err == nil {
+			rendition.Bitrate = bitrate
+		}
+		for _, stream := range program.Streams {
+			switch stream.CodecType {
+			case "video":
+				if rendition.VideoCodec == "" && stream.Disposition.AttachedPic == 0 {
+					rendition.VideoCodec = stream.CodecName
+					rendition.Width = stream.Width
+					rendition.Height = stream.Height
+				}
+			case "audio":
+				if rendition.AudioCodec == "" {
+					rendition.AudioCodec = stream.CodecName
+				}
+			}
+		}
+		renditions = append(renditions, rendition)
+	}
+	return renditions
+}
+
+// timecodePattern matches SMPTE timecodes in non-drop (HH:MM:SS:FF) and
+// drop-frame (HH:MM:SS;FF or HH:MM:SS.FF) notation
+var timecodePattern = regexp.MustCompile(`^\d{2}:\d{2}:\d{2}[:;.]\d{2,3}$`)
+
+// extractTimecode returns the starting timecode from the container tags or,
+// failing that, from the first stream carrying one (typically a tmcd data
+// stream or the video stream)
+func extractTimecode(probeData *ffprobe.ProbeData) string {
+	candidates := make([]map[string]string, 0, len(probeData.Streams)+1)
+	if probeData.Format != nil {
+		candidates = append(candidates, probeData.Format.Tags)
+	}
+	for _, stream := range probeData.Streams {
+		candidates = append(candidates, stream.Tags)
+	}
+
+	for _, tags := range candidates {
+		if tc := strings.TrimSpace(tags["timecode"]); timecodePattern.MatchString(tc) {
+			return tc
+		}
+	}
+	return ""
+}
+
 func (a *Analyzer) extractFormatInfo(format *ffprobe.Format) *FormatInfo {
 	return &FormatInfo{
 		FormatName:     format.FormatName,
@@ -142,32 +424,147 @@ func (a *Analyzer) extractFormatInfo(format *ffprobe.Format) *FormatInfo {
 		Bitrate:        format.Bitrate,
 		ProbeScore:     format.ProbeScore,
 		Tags:           format.Tags,
+		Fragmented:     detector.IsFragmentedMP4(format.FormatName, format.Tags),
+	}
+}
+
+// selectStream returns the stream with the given index as the only stream,
+// or an error naming the indexes the input does have
+func selectStream(streams []ffprobe.Stream, index int) ([]ffprobe.Stream, error) {
+	indexes := make([]string, 0, len(streams))
+	for _, stream := range streams {
+		if stream.Index == index {
+			return []ffprobe.Stream{stream}, nil
+		}
+		indexes = append(indexes, fmt.Sprintf("%d (%s)", stream.Index, stream.CodecType))
+	}
+	if len(indexes) == 0 {
+		return nil, fmt.Errorf("stream %d does not exist: the input has no streams", index)
+	}
+	return nil, fmt.Errorf("stream %d does not exist: the input has streams %s", index, strings.Join(indexes, ", "))
+}
+
+// isRemoteInput reports whether input is a URL rather than a local path
+func isRemoteInput(input string) bool {
+	return strings.Contains(input, "://")
+}
+
+// hashFile returns the hex SHA-256 of a file, streaming its contents so
+// large files are never loaded into memory
+func hashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// resolveDuration records where the format duration comes from. Live
+// captures often have no container duration; for those the longest stream
+// duration is used instead so later calculations have a usable value.
+func (a *Analyzer) resolveDuration(format *FormatInfo, streams []ffprobe.Stream) {
+	if format.Duration > 0 {
+		format.DurationSource = DurationSourceContainer
+		return
+	}
+	if !detector.IsLiveCaptureFormat(format.FormatName) {
+		return
+	}
+
+	var longest float64
+	for _, stream := range streams {
+		if stream.Duration > longest {
+			longest = stream.Duration
+		}
+	}
+	if longest > 0 {
+		format.Duration = longest
+		format.DurationSource = DurationSourceStream
 	}
 }
 
 func (a *Analyzer) extractVideoInfo(stream *ffprobe.Stream) *VideoInfo {
 	return &VideoInfo{
-		Index:          stream.Index,
-		Codec:          stream.CodecName,
-		CodecLongName:  stream.CodecLongName,
-		Profile:        stream.Profile,
-		Width:          stream.Width,
-		Height:         stream.Height,
-		AspectRatio:    stream.DisplayAspectRatio,
-		PixelFormat:    stream.PixFmt,
-		FrameRate:      stream.RFrameRate,
-		AvgFrameRate:   stream.AvgFrameRate,
-		Bitrate:        stream.Bitrate,
-		Duration:       stream.Duration,
-		FrameCount:     stream.NbFramesInt,
-		Level:          stream.Level,
-		ColorSpace:     stream.ColorSpace,
-		ColorPrimaries: stream.ColorPrimaries,
-		ColorTransfer:  stream.ColorTransfer,
-		HasBFrames:     stream.HasBFrames,
+		Index:             stream.Index,
+		Codec:             stream.CodecName,
+		CodecLongName:     stream.CodecLongName,
+		CodecTagString:    stream.CodecTagString,
+		Profile:           stream.Profile,
+		Width:             stream.Width,
+		Height:            stream.Height,
+		AspectRatio:       stream.DisplayAspectRatio,
+		SampleAspectRatio: stream.SampleAspectRatio,
+		TimeBase:          stream.TimeBase,
+		PixelFormat:       stream.PixFmt,
+		FrameRate:         stream.RFrameRate,
+		AvgFrameRate:      stream.AvgFrameRate,
+		FrameRateValue:    parseRate(stream.RFrameRate),
+		AvgFrameRateValue: parseRate(stream.AvgFrameRate),
+		Bitrate:           stream.Bitrate,
+		Duration:          stream.Duration,
+		StartTime:         parseTime(stream.StartTime),
+		FrameCount:        stream.NbFramesInt,
+		Level:             stream.Level,
+		ColorSpace:        stream.ColorSpace,
+		ColorPrimaries:    stream.ColorPrimaries,
+		ColorTransfer:     stream.ColorTransfer,
+		HasBFrames:        stream.HasBFrames,
+		Encoder:           stream.Tags["encoder"],
+		HDR:               extractHDRMetadata(stream),
+		Stereo3D:          extractStereo3D(stream),
+		Default:           stream.Disposition.Default == 1,
+		Forced:            stream.Disposition.Forced == 1,
+		AttachedPic:       stream.Disposition.AttachedPic == 1,
 	}
 }
 
+// extractStereo3D returns the stereoscopic 3D layout from a stream's side
+// data, with "(inverted)" appended when the views are swapped, or "" for 2D
+func extractStereo3D(stream *ffprobe.Stream) string {
+	for _, sd := range stream.SideDataList {
+		if sd.SideDataType != ffprobe.SideDataStereo3D || sd.Type == "" || sd.Type == "2D" {
+			continue
+		}
+		if sd.Inverted != 0 {
+			return sd.Type + " (inverted)"
+		}
+		return sd.Type
+	}
+	return ""
+}
+
+// extractHDRMetadata decodes the HDR10 mastering display and content light
+// level side data of a stream, returning nil when neither is present
+func extractHDRMetadata(stream *ffprobe.Stream) *detector.HDRMetadata {
+	var hdr *detector.HDRMetadata
+	for _, sd := range stream.SideDataList {
+		switch sd.SideDataType {
+		case ffprobe.SideDataMasteringDisplay:
+			if hdr == nil {
+				hdr = &detector.HDRMetadata{}
+			}
+			hdr.MasteringDisplay = true
+			// Luminances are rationals like frame rates, e.g. "10000000/10000"
+			hdr.MaxLuminance = parseRate(sd.MaxLuminance)
+			hdr.MinLuminance = parseRate(sd.MinLuminance)
+		case ffprobe.SideDataContentLight:
+			if hdr == nil {
+				hdr = &detector.HDRMetadata{}
+			}
+			hdr.ContentLight = true
+			hdr.MaxCLL = sd.MaxContent
+			hdr.MaxFALL = sd.MaxAverage
+		}
+	}
+	return hdr
+}
+
 func (a *Analyzer) extractAudioInfo(stream *ffprobe.Stream) *AudioInfo {
 	sampleRate := 0
 	if stream.SampleRate != "" {
@@ -175,36 +572,142 @@ func (a *Analyzer) extractAudioInfo(stream *ffprobe.Stream) *AudioInfo {
 	}
 	
 	return &AudioInfo{
-		Index:         stream.Index,
-		Codec:         stream.CodecName,
-		CodecLongName: stream.CodecLongName,
-		Profile:       stream.Profile,
-		Channels:      stream.Channels,
-		ChannelLayout: stream.ChannelLayout,
-		SampleRate:    sampleRate,
-		SampleFormat:  stream.SampleFmt,
-		Bitrate:       stream.Bitrate,
-		Duration:      stream.Duration,
+		Index:                   stream.Index,
+		Codec:                   stream.CodecName,
+		CodecLongName:           stream.CodecLongName,
+		Profile:                 stream.Profile,
+		Channels:                stream.Channels,
+		ChannelLayout:           stream.ChannelLayout,
+		ChannelLayoutNormalized: detector.NormalizeChannelLayout(stream.ChannelLayout),
+		SampleRate:              sampleRate,
+		SampleFormat:            stream.SampleFmt,
+		Bitrate:                 stream.Bitrate,
+		Duration:                stream.Duration,
+		StartTime:               parseTime(stream.StartTime),
+		TimeBase:                stream.TimeBase,
+		InitialPadding:          stream.InitialPadding,
+		Encoder:                 stream.Tags["encoder"],
+		Language:                stream.Tags["language"],
+		Tags:                    stream.Tags,
+		Default:                 stream.Disposition.Default == 1,
+		Forced:                  stream.Disposition.Forced == 1,
 	}
 }
 
+// parseTime converts an ffprobe time string such as "0.021333" to seconds,
+// returning 0 when the value is missing or "N/A"
+func parseTime(value string) float64 {
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+	return seconds
+}
+
+// parseRate converts an ffprobe rational such as "30000/1001" to a float.
+// It returns 0 when the rate is missing, malformed or undefined ("0/0").
+func parseRate(rate string) float64 {
+	value, err := ffprobe.ParseRational(rate)
+	if err != nil || value < 0 {
+		return 0
+	}
+	return value
+}
+
+// videoFrameRate returns the average frame rate of video, falling back to
+// the nominal rate when the average is undefined
+func videoFrameRate(video *VideoInfo) float64 {
+	if video.AvgFrameRateValue > 0 {
+		return video.AvgFrameRateValue
+	}
+	return video.FrameRateValue
+}
+
 func (a *Analyzer) extractStreamInfo(stream *ffprobe.Stream) StreamInfo {
 	return StreamInfo{
-		Index:     stream.Index,
-		Type:      stream.CodecType,
-		Codec:     stream.CodecName,
-		CodecType: stream.CodecType,
-		Tags:      stream.Tags,
+		Index:       stream.Index,
+		Type:        stream.CodecType,
+		Codec:       stream.CodecName,
+		CodecType:   stream.CodecType,
+		Tags:        stream.Tags,
+		Default:     stream.Disposition.Default == 1,
+		Forced:      stream.Disposition.Forced == 1,
+		AttachedPic: stream.Disposition.AttachedPic == 1,
 	}
 }
 
+// defaultPeakBitrateWindow is the rolling window used for PeakBitrate, a
+// typical player buffer length
+const defaultPeakBitrateWindow = 2.0
+
 // DetailedAnalysis contains extended analysis results
 type DetailedAnalysis struct {
-	MediaInfo       *MediaInfo              `json:"media_info"`
-	Problems        []detector.Problem      `json:"problems,omitempty"`
-	Packets         []PacketData            `json:"packets,omitempty"`
-	Frames          []FrameData             `json:"frames,omitempty"`
-	BitrateTimeline []detector.BitratePoint `json:"bitrate_timeline,omitempty"`
+	MediaInfo         *MediaInfo               `json:"media_info"`
+	Problems          []detector.Problem       `json:"problems,omitempty"`
+	Resolved          []detector.Problem       `json:"resolved_problems,omitempty"` // Baseline problems no longer present
+	Packets           []PacketData             `json:"packets,omitempty"`
+	Frames            []FrameData              `json:"frames,omitempty"`
+	BitrateTimeline   []detector.BitratePoint  `json:"bitrate_timeline,omitempty"`
+	QualityTimeline   []detector.QualitySecond `json:"quality_timeline,omitempty"`    // Per-second frame aggregates
+	PeakBitrate       float64                  `json:"peak_bitrate,omitempty"`        // Highest bitrate over any PeakBitrateWindow seconds
+	PeakBitrateWindow float64                  `json:"peak_bitrate_window,omitempty"` // Rolling window length in seconds
+	VideoStats        *VideoStats              `json:"video_stats,omitempty"`         // Per-second video bitrate statistics
+	Diagnostics       *Diagnostics             `json:"diagnostics,omitempty"`
+}
+
+// Summary is a compact overview of an analysis, suitable for notifications
+// and indexing
+type Summary struct {
+	Input         string         `json:"input"`
+	AnalyzedAt    time.Time      `json:"analyzed_at"`
+	Format        string         `json:"format,omitempty"`
+	Duration      float64        `json:"duration,omitempty"`
+	VideoCodec    string         `json:"video_codec,omitempty"`
+	Width         int            `json:"width,omitempty"`
+	Height        int            `json:"height,omitempty"`
+	AudioCodec    string         `json:"audio_codec,omitempty"`
+	ProblemCounts map[string]int `json:"problem_counts"`
+}
+
+// Summary condenses the analysis into its key fields and per-severity
+// problem counts
+func (d *DetailedAnalysis) Summary() *Summary {
+	summary := &Summary{
+		ProblemCounts: map[string]int{
+			"error":    0,
+			"critical": 0,
+			"warning":  0,
+			"info":     0,
+		},
+	}
+
+	if info := d.MediaInfo; info != nil {
+		summary.Input = info.Input
+		summary.AnalyzedAt = info.AnalyzedAt
+		if info.Format != nil {
+			summary.Format = info.Format.FormatName
+			summary.Duration = info.Format.Duration
+		}
+		if info.VideoStream != nil {
+			summary.VideoCodec = info.VideoStream.Codec
+			summary.Width = info.VideoStream.Width
+			summary.Height = info.VideoStream.Height
+		}
+		if info.AudioStream != nil {
+			summary.AudioCodec = info.AudioStream.Codec
+		}
+	}
+
+	for _, p := range d.Problems {
+		summary.ProblemCounts[strings.ToLower(p.Severity.String())]++
+	}
+
+	return summary
+}
+
+// Errors returns the number of error and critical problems in the summary
+func (s *Summary) Errors() int {
+	return s.ProblemCounts["error"] + s.ProblemCounts["critical"]
 }
 
 // PacketData represents analyzed packet information
@@ -220,95 +723,220 @@ type PacketData struct {
 
 // FrameData represents analyzed frame information
 type FrameData struct {
-	MediaType   string  `json:"media_type"`
-	StreamIndex int     `json:"stream_index"`
-	KeyFrame    bool    `json:"key_frame"`
-	PTS         float64 `json:"pts"`
-	DTS         float64 `json:"dts"`
-	Duration    float64 `json:"duration"`
-	Size        int     `json:"size"`
-	PictType    string  `json:"pict_type,omitempty"`
-	Width       int     `json:"width,omitempty"`
-	Height      int     `json:"height,omitempty"`
-	PixFmt      string  `json:"pix_fmt,omitempty"`
+	MediaType       string  `json:"media_type"`
+	StreamIndex     int     `json:"stream_index"`
+	KeyFrame        bool    `json:"key_frame"`
+	PTS             float64 `json:"pts"`
+	DTS             float64 `json:"dts"`
+	Duration        float64 `json:"duration"`
+	Size            int     `json:"size"`
+	PictType        string  `json:"pict_type,omitempty"`
+	Width           int     `json:"width,omitempty"`
+	Height          int     `json:"height,omitempty"`
+	PixFmt          string  `json:"pix_fmt,omitempty"`
+	CodedNumber     int     `json:"coded_picture_number,omitempty"`
+	RepeatPict      int     `json:"repeat_pict,omitempty"`
+	InterlacedFrame bool    `json:"interlaced_frame,omitempty"`
+	TopFieldFirst   bool    `json:"top_field_first,omitempty"`
+	AttachedPic     bool    `json:"attached_pic,omitempty"` // Frame of a cover art stream
 }
 
 // AnalyzeWithDetails performs comprehensive media analysis including packets and frames
 func (a *Analyzer) AnalyzeWithDetails(input string) (*DetailedAnalysis, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(a.options.Timeout)*time.Second)
+	return a.AnalyzeWithDetailsContext(context.Background(), input)
+}
+
+// AnalyzeWithDetailsContext is AnalyzeWithDetails with a parent context;
+// cancelling it stops ffprobe and returns ffprobe.ErrCancelled
+func (a *Analyzer) AnalyzeWithDetailsContext(ctx context.Context, input string) (*DetailedAnalysis, error) {
+	if input == ffprobe.StdinInput && (a.options.AnalyzePackets || a.options.AnalyzeFrames) {
+		return a.analyzeStdin(ctx)
+	}
+	if a.options.CacheDir == "" {
+		return a.analyzeWithDetails(ctx, input)
+	}
+
+	// Options that only affect console output must not change the cache key
+	keyOptions := a.options
+	keyOptions.CacheDir = ""
+	keyOptions.Verbose = false
+	keyOptions.ShowCommand = false
+	keyOptions.SlowWarningThreshold = 0
+
+	key, ok := cache.Key(input, keyOptions)
+	if !ok {
+		return a.analyzeWithDetails(ctx, input)
+	}
+
+	c := cache.New(a.options.CacheDir)
+	var cached DetailedAnalysis
+	if hit, err := c.Load(key, &cached); err != nil {
+		if a.options.Verbose {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to read cache: %v\n", err)
+		}
+	} else if hit {
+		if a.options.Verbose {
+			fmt.Fprintf(os.Stderr, "Using cached analysis for %s\n", input)
+		}
+		if cached.Diagnostics != nil {
+			cached.Diagnostics.FromCache = true
+		}
+		return &cached, nil
+	}
+
+	result, err := a.analyzeWithDetails(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Store(key, result); err != nil && a.options.Verbose {
+		fmt.Fprintf(os.Stderr, "Warning: Failed to write cache: %v\n", err)
+	}
+	return result, nil
+}
+
+func (a *Analyzer) analyzeWithDetails(ctx context.Context, input string) (*DetailedAnalysis, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(a.options.Timeout)*time.Second)
 	defer cancel()
 
 	// Get basic media info
-	mediaInfo, err := a.Analyze(input)
+	mediaInfo, err := a.AnalyzeContext(ctx, input)
 	if err != nil {
 		return nil, err
 	}
 
 	result := &DetailedAnalysis{
-		MediaInfo: mediaInfo,
-		Problems:  make([]detector.Problem, 0),
+		MediaInfo:   mediaInfo,
+		Problems:    make([]detector.Problem, 0),
+		Diagnostics: &Diagnostics{profileDetectors: a.options.ProfileDetectors},
 	}
+	diag := result.Diagnostics
+	diag.addProbe("streams", ProbeOK, len(mediaInfo.Streams), nil)
 
 	// Initialize detector
-	det := detector.New()
+	det := detector.New(a.options.DetectorConfig)
+	det.SetSeverityOverrides(a.options.SeverityOverrides)
+	det.SetCompatMatrix(a.options.CompatMatrix)
+	det.SetMaxProblems(a.options.MaxProblems)
+	var packetInfos []detector.PacketInfo
+	var frameInfos []detector.FrameInfo
+	// Whether the probes' results were kept whole rather than cut at
+	// MaxPackets/MaxFrames
+	var packetsComplete, framesComplete bool
 
 	// Analyze packets if requested
 	if a.options.AnalyzePackets {
 		if a.options.Verbose {
-			fmt.Printf("Analyzing packets...\n")
+			fmt.Fprintln(os.Stderr, "Analyzing packets...")
+		}
+		stopSlowWarning := a.warnIfSlow("packet")
+		// Packets are decoded as ffprobe writes them; once MaxPackets are kept
+		// the next one stops the probe instead of reading to the end
+		truncated := false
+		warnings, err := a.ffprobe.StreamPackets(ctx, input, func(packet ffprobe.Packet) bool {
+			if a.options.MaxPackets > 0 && len(result.Packets) >= a.options.MaxPackets {
+				truncated = true
+				return false
+			}
+			result.Packets = append(result.Packets, PacketData{
+				PTS:         packet.PTS,
+				DTS:         packet.DTS,
+				Size:        packet.Size,
+				StreamIndex: packet.StreamIndex,
+				CodecType:   packet.CodecType,
+				Duration:    packet.Duration,
+				Flags:       packet.Flags,
+			})
+			// Cover art is a "video" stream to ffprobe; keep its image out
+			// of the video bitrate series
+			codecType := packet.CodecType
+			if mediaInfo.attachedPics[packet.StreamIndex] {
+				codecType = "cover_art"
+			}
+			packetInfos = append(packetInfos, detector.PacketInfo{
+				PTS:         packet.PTS,
+				DTS:         packet.DTS,
+				Size:        packet.Size,
+				StreamIndex: packet.StreamIndex,
+				CodecType:   codecType,
+				Duration:    packet.Duration,
+				PTSMissing:  !packet.HasPTS,
+			})
+			return true
+		})
+		stopSlowWarning()
+		mediaInfo.FFprobeLog = mergeFFprobeLog(mediaInfo.FFprobeLog, warnings)
+		if errors.Is(err, ffprobe.ErrCancelled) {
+			return nil, err
 		}
-		packetsData, err := a.ffprobe.ProbePackets(ctx, input)
 		if err != nil {
+			// A partial packet list would skew every packet detector
+			result.Packets, packetInfos = nil, nil
+			diag.addProbe("packets", ProbeFailed, 0, err)
 			if a.options.Verbose {
-				fmt.Printf("Warning: Failed to analyze packets: %v\n", err)
+				fmt.Fprintf(os.Stderr, "Warning: Failed to analyze packets: %v\n", err)
 			}
 		} else {
-			// Convert and limit packets
-			for i, packet := range packetsData.Packets {
-				if a.options.MaxPackets > 0 && i >= a.options.MaxPackets {
-					break
-				}
-				result.Packets = append(result.Packets, PacketData{
-					PTS:         packet.PTS,
-					DTS:         packet.DTS,
-					Size:        packet.Size,
-					StreamIndex: packet.StreamIndex,
-					CodecType:   packet.CodecType,
-					Duration:    packet.Duration,
-					Flags:       packet.Flags,
-				})
-			}
+			diag.addProbe("packets", ProbeOK, len(result.Packets), nil)
+			packetsComplete = !truncated
 
 			// Detect packet-based problems
 			if len(result.Packets) > 0 {
-				packetInfos := make([]detector.PacketInfo, 0, len(result.Packets))
-				for _, p := range result.Packets {
-					packetInfos = append(packetInfos, detector.PacketInfo{
-						PTS:         p.PTS,
-						DTS:         p.DTS,
-						Size:        p.Size,
-						StreamIndex: p.StreamIndex,
-						Duration:    p.Duration,
+				diag.runDetector("DetectBitrateVariations", func() { det.DetectBitrateVariations(packetInfos) })
+				diag.runDetector("DetectPacketLoss", func() { det.DetectPacketLoss(packetInfos) })
+				diag.runDetector("DetectNegativeStartPTS", func() { det.DetectNegativeStartPTS(packetInfos) })
+
+				// A partial packet sample only gives a usable average once it spans
+				// a meaningful stretch of the stream
+				if video := mediaInfo.VideoStream; video != nil {
+					measured, span := detector.MeasuredStreamBitrate(packetInfos, video.Index)
+					if packetsComplete || span >= 30 {
+						video.MeasuredBitrate = int64(measured)
+						diag.runDetector("DetectDeclaredBitrateMismatch", func() {
+							det.DetectDeclaredBitrateMismatch(video.Bitrate, measured, span, video.Index)
+						})
+					}
+				}
+
+				// Summed durations are only meaningful over the complete packet list
+				if audio := mediaInfo.AudioStream; audio != nil && packetsComplete {
+					diag.runDetector("DetectAudioTruncation", func() {
+						det.DetectAudioTruncation(packetInfos, audio.Index, audio.Duration)
 					})
 				}
-				det.DetectBitrateVariations(packetInfos)
-				det.DetectPacketLoss(packetInfos)
-				
+
 				// Generate bitrate timeline
-				result.BitrateTimeline = detector.GenerateBitrateTimeline(packetInfos, 1.0)
+				result.BitrateTimeline = detector.GenerateBitrateTimelinePerStream(packetInfos, 1.0)
+				result.VideoStats = videoStats(result.BitrateTimeline)
+
+				result.PeakBitrateWindow = a.options.PeakBitrateWindow
+				if result.PeakBitrateWindow <= 0 {
+					result.PeakBitrateWindow = defaultPeakBitrateWindow
+				}
+				result.PeakBitrate = detector.PeakRollingBitrate(packetInfos, result.PeakBitrateWindow)
 			}
 		}
+	} else {
+		diag.addProbe("packets", ProbeSkipped, 0, nil)
 	}
 
 	// Analyze frames if requested
 	if a.options.AnalyzeFrames {
 		if a.options.Verbose {
-			fmt.Printf("Analyzing frames...\n")
+			fmt.Fprintln(os.Stderr, "Analyzing frames...")
 		}
+		stopSlowWarning := a.warnIfSlow("frame")
 		framesData, err := a.ffprobe.ProbeFrames(ctx, input)
+		stopSlowWarning()
+		if errors.Is(err, ffprobe.ErrCancelled) {
+			return nil, err
+		}
+		if framesData != nil {
+			mediaInfo.FFprobeLog = mergeFFprobeLog(mediaInfo.FFprobeLog, framesData.Warnings)
+		}
 		if err != nil {
+			diag.addProbe("frames", ProbeFailed, 0, err)
 			if a.options.Verbose {
-				fmt.Printf("Warning: Failed to analyze frames: %v\n", err)
+				fmt.Fprintf(os.Stderr, "Warning: Failed to analyze frames: %v\n", err)
 			}
 		} else {
 			// Convert and limit frames
@@ -317,49 +945,279 @@ func (a *Analyzer) AnalyzeWithDetails(input string) (*DetailedAnalysis, error) {
 					break
 				}
 				result.Frames = append(result.Frames, FrameData{
-					MediaType:   frame.MediaType,
-					StreamIndex: frame.StreamIndex,
-					KeyFrame:    frame.KeyFrame,
-					PTS:         frame.PTS,
-					DTS:         frame.DTS,
-					Duration:    frame.Duration,
-					Size:        frame.Size,
-					PictType:    frame.PictType,
-					Width:       frame.Width,
-					Height:      frame.Height,
-					PixFmt:      frame.PixFmt,
+					MediaType:       frame.MediaType,
+					StreamIndex:     frame.StreamIndex,
+					KeyFrame:        frame.KeyFrame,
+					PTS:             frame.PTS,
+					DTS:             frame.DTS,
+					Duration:        frame.Duration,
+					Size:            frame.Size,
+					PictType:        frame.PictType,
+					Width:           frame.Width,
+					Height:          frame.Height,
+					PixFmt:          frame.PixFmt,
+					CodedNumber:     frame.CodedPictureNumber,
+					RepeatPict:      frame.RepeatPict,
+					InterlacedFrame: frame.InterlacedFrame == 1,
+					TopFieldFirst:   frame.TopFieldFirst == 1,
+					AttachedPic:     frame.MediaType == "video" && mediaInfo.attachedPics[frame.StreamIndex],
 				})
 			}
+			diag.addProbe("frames", ProbeOK, len(result.Frames), nil)
+			framesComplete = len(result.Frames) == len(framesData.Frames)
 
 			// Detect frame-based problems
 			if len(result.Frames) > 0 {
-				frameInfos := make([]detector.FrameInfo, 0, len(result.Frames))
-				for _, f := range result.Frames {
-					frameInfos = append(frameInfos, detector.FrameInfo{
-						MediaType:   f.MediaType,
-						StreamIndex: f.StreamIndex,
-						KeyFrame:    f.KeyFrame,
-						PTS:         f.PTS,
-						DTS:         f.DTS,
-						Duration:    f.Duration,
-						Size:        f.Size,
-						PictType:    f.PictType,
+				frameInfos = toFrameInfos(result.Frames)
+				diag.runDetector("DetectKeyframeIssues", func() { det.DetectKeyframeIssues(frameInfos) })
+				diag.runDetector("DetectGOPProblems", func() { det.DetectGOPProblems(frameInfos) })
+				diag.runDetector("DetectTimestampIssues", func() { det.DetectTimestampIssues(frameInfos) })
+				diag.runDetector("DetectTimestampPrecision", func() { det.DetectTimestampPrecision(frameInfos) })
+				diag.runDetector("DetectUniformFrameSizes", func() { det.DetectUniformFrameSizes(frameInfos) })
+				diag.runDetector("DetectDuplicateFrames", func() { det.DetectDuplicateFrames(frameInfos) })
+				diag.runDetector("DetectCodedNumberMismatch", func() { det.DetectCodedNumberMismatch(frameInfos) })
+				diag.runDetector("DetectInterlacing", func() { det.DetectInterlacing(frameInfos) })
+				diag.runDetector("DetectResolutionChanges", func() { det.DetectResolutionChanges(frameInfos) })
+
+				result.QualityTimeline = detector.GenerateQualityTimeline(frameInfos)
+
+				if video := mediaInfo.VideoStream; video != nil {
+					diag.runDetector("DetectBFramePyramid", func() {
+						det.DetectBFramePyramid(frameInfos, video.Index, a.options.Streaming || isRemoteInput(input))
+					})
+					diag.runDetector("DetectFrameDurationVariance", func() {
+						det.DetectFrameDurationVariance(frameInfos, video.Index, video.FrameRateValue, video.AvgFrameRateValue)
 					})
+
+					if audio := mediaInfo.AudioStream; audio != nil {
+						var videoFrames, audioFrames []detector.FrameInfo
+						for _, f := range frameInfos {
+							switch {
+							case f.MediaType == "video" && f.StreamIndex == video.Index:
+								videoFrames = append(videoFrames, f)
+							case f.MediaType == "audio" && f.StreamIndex == audio.Index:
+								audioFrames = append(audioFrames, f)
+							}
+						}
+						diag.runDetector("DetectAVSync", func() { det.DetectAVSync(videoFrames, audioFrames) })
+					}
 				}
-				det.DetectKeyframeIssues(frameInfos)
-				det.DetectTimestampIssues(frameInfos)
 			}
 		}
+	} else {
+		diag.addProbe("frames", ProbeSkipped, 0, nil)
+	}
+
+	if a.options.Profile != nil {
+		// A single selected stream says nothing about the file's layout
+		if a.options.Stream == nil {
+			diag.runDetector("DetectStreamLayout", func() { det.DetectStreamLayout(a.options.Profile, mediaInfo.StreamCounts) })
+		}
+		diag.runDetector("DetectAudioSampleRate", func() {
+			for _, audio := range mediaInfo.AudioStreams {
+				det.DetectAudioSampleRate(a.options.Profile, audio.SampleRate, audio.Index)
+			}
+		})
+		diag.runDetector("DetectAudioSpec", func() {
+			for _, audio := range mediaInfo.AudioStreams {
+				det.DetectAudioSpec(a.options.Profile, audio.Codec, audio.Channels, audio.Index)
+			}
+		})
+	}
+
+	if mediaInfo.Format != nil {
+		format := mediaInfo.Format
+		diag.runDetector("DetectContainerProblems", func() {
+			det.DetectContainerProblems(detector.ContainerParams{
+				Input:          input,
+				FormatName:     format.FormatName,
+				Duration:       format.Duration,
+				DurationSource: format.DurationSource,
+				Size:           format.Size,
+				ProbeScore:     format.ProbeScore,
+			})
+		})
+	}
+
+	if mediaInfo.StartTimecode != "" {
+		diag.runDetector("DetectTimecode", func() { det.DetectTimecode(mediaInfo.StartTimecode) })
+	}
+
+	// Cross-check the two probes against each other
+	if len(packetInfos) > 0 && len(frameInfos) > 0 {
+		diag.runDetector("DetectPacketFrameDrift", func() { det.DetectPacketFrameDrift(packetInfos, frameInfos) })
+		// Our own MaxPackets/MaxFrames cut-offs would always disagree, so only
+		// compare complete results
+		if packetsComplete && framesComplete {
+			diag.runDetector("DetectProbeDurationMismatch", func() { det.DetectProbeDurationMismatch(packetInfos, frameInfos) })
+		}
+	}
+
+	if video := mediaInfo.VideoStream; video != nil {
+		// Prefer the declared bitrate; many containers (MKV, WebM) only
+		// have the one measured from packets
+		videoBitrate := video.Bitrate
+		if videoBitrate == 0 {
+			videoBitrate = video.MeasuredBitrate
+		}
+		videoParams := detector.VideoParams{
+			Index:        video.Index,
+			Codec:        video.Codec,
+			Profile:      video.Profile,
+			Width:        video.Width,
+			Height:       video.Height,
+			FrameRate:    video.FrameRate,
+			FPS:          video.FrameRateValue,
+			AvgFrameRate: video.AvgFrameRate,
+			AvgFPS:       video.AvgFrameRateValue,
+			PixelFormat:  video.PixelFormat,
+			Bitrate:      videoBitrate,
+		}
+		diag.runDetector("DetectVideoProblems", func() { det.DetectVideoProblems(videoParams) })
+		diag.runDetector("DetectVariableFrameRate", func() { det.DetectVariableFrameRate(videoParams) })
+		diag.runDetector("DetectHDR10LightLevel", func() {
+			det.DetectHDR10LightLevel(video.ColorTransfer, video.HDR, video.Index)
+		})
+		diag.runDetector("DetectColorMatrix", func() {
+			det.DetectColorMatrix(video.ColorSpace, video.ColorTransfer, video.PixelFormat, video.Width, video.Height, video.Index)
+		})
+		if video.Stereo3D != "" {
+			diag.runDetector("DetectStereo3D", func() {
+				det.DetectStereo3D(video.Stereo3D, a.options.Profile, video.Index)
+			})
+		}
+		diag.runDetector("DetectUndefinedSAR", func() {
+			det.DetectUndefinedSAR(video.SampleAspectRatio, video.Width, video.Height, video.Index)
+		})
+		if a.options.Profile != nil {
+			diag.runDetector("DetectAnamorphicForProfile", func() {
+				det.DetectAnamorphicForProfile(a.options.Profile, video.SampleAspectRatio, video.Width, video.Height, video.Index)
+			})
+			diag.runDetector("DetectVideoSpec", func() { det.DetectVideoSpec(a.options.Profile, videoParams) })
+		}
+		diag.runDetector("DetectNonstandardResolution", func() {
+			det.DetectNonstandardResolution(video.Width, video.Height, video.Index)
+		})
+	}
+
+	if audio := mediaInfo.AudioStream; audio != nil {
+		diag.runDetector("DetectChannelLayoutMismatch", func() {
+			det.DetectChannelLayoutMismatch(audio.ChannelLayout, audio.Channels, audio.Index)
+		})
+	}
+
+	if mediaInfo.Format != nil && mediaInfo.Format.Fragmented {
+		diag.runDetector("DetectUnusualTimescale", func() {
+			if video := mediaInfo.VideoStream; video != nil {
+				det.DetectUnusualTimescale(video.TimeBase, 0, video.Index)
+			}
+			if audio := mediaInfo.AudioStream; audio != nil {
+				det.DetectUnusualTimescale(audio.TimeBase, audio.SampleRate, audio.Index)
+			}
+		})
+	}
+
+	if video := mediaInfo.VideoStream; video != nil && mediaInfo.Format != nil {
+		diag.runDetector("DetectHEVCTag", func() {
+			det.DetectHEVCTag(video.Codec, video.CodecTagString, mediaInfo.Format.FormatName, video.Index)
+		})
+	}
+
+	if video, audio := mediaInfo.VideoStream, mediaInfo.AudioStream; video != nil && audio != nil {
+		diag.runDetector("DetectQualityTierMismatch", func() {
+			det.DetectQualityTierMismatch(video.Codec, video.Bitrate, audio.Codec, audio.Bitrate, audio.Index)
+		})
+	}
+
+	if cover := mediaInfo.CoverArt; cover != nil {
+		diag.runDetector("DetectCoverArt", func() {
+			det.DetectCoverArt(cover.Codec, cover.Width, cover.Height, cover.Index, mediaInfo.VideoStream != nil)
+		})
+	}
+
+	if len(mediaInfo.Renditions) > 1 {
+		renditions := make([]detector.Rendition, len(mediaInfo.Renditions))
+		for i, r := range mediaInfo.Renditions {
+			name := fmt.Sprintf("program %d", r.ProgramID)
+			if r.Bitrate > 0 {
+				name += fmt.Sprintf(" (%.2f Mbps)", float64(r.Bitrate)/1000000)
+			}
+			renditions[i] = detector.Rendition{Name: name, VideoCodec: r.VideoCodec, AudioCodec: r.AudioCodec}
+		}
+		diag.runDetector("DetectRenditionCodecs", func() { det.DetectRenditionCodecs(renditions) })
+	}
+
+	for _, data := range mediaInfo.DataStreams {
+		diag.runDetector("DetectDataStream", func() {
+			det.DetectDataStream(data.Type, data.Codec, data.Index)
+		})
+	}
+
+	diag.runDetector("DetectDeprecatedCodec", func() {
+		if video := mediaInfo.VideoStream; video != nil {
+			det.DetectDeprecatedCodec(video.Codec, video.Index)
+		}
+		if audio := mediaInfo.AudioStream; audio != nil {
+			det.DetectDeprecatedCodec(audio.Codec, audio.Index)
+		}
+	})
+
+	diag.runDetector("DetectOldEncoder", func() {
+		seen := make(map[string]bool)
+		if video := mediaInfo.VideoStream; video != nil && video.Encoder != "" {
+			seen[video.Encoder] = true
+			det.DetectOldEncoder(video.Encoder, video.Index)
+		}
+		if audio := mediaInfo.AudioStream; audio != nil && audio.Encoder != "" && !seen[audio.Encoder] {
+			det.DetectOldEncoder(audio.Encoder, audio.Index)
+		}
+	})
+
+	if video := mediaInfo.VideoStream; video != nil && len(mediaInfo.AudioStreams) > 0 {
+		tracks := make([]detector.TrackStart, 0, len(mediaInfo.AudioStreams))
+		for _, audio := range mediaInfo.AudioStreams {
+			tracks = append(tracks, detector.TrackStart{Index: audio.Index, Language: audio.Language, StartTime: audio.StartTime})
+		}
+		diag.runDetector("DetectAudioTrackDelays", func() { det.DetectAudioTrackDelays(video.StartTime, tracks) })
+	}
+
+	if audio := mediaInfo.AudioStream; audio != nil {
+		var formatTags map[string]string
+		if mediaInfo.Format != nil {
+			formatTags = mediaInfo.Format.Tags
+		}
+		diag.runDetector("DetectEncoderDelay", func() {
+			det.DetectEncoderDelay(audio.Codec, audio.StartTime, audio.InitialPadding, audio.Tags, formatTags, audio.Index)
+		})
 	}
 
 	// Check compatibility issues
 	if mediaInfo.VideoStream != nil {
-		det.AnalyzeCompatibility(
-			mediaInfo.VideoStream.Codec,
-			mediaInfo.VideoStream.Profile,
-			mediaInfo.VideoStream.Level,
-			mediaInfo.Format.FormatName,
-		)
+		diag.runDetector("AnalyzeCompatibility", func() {
+			det.AnalyzeCompatibility(
+				mediaInfo.VideoStream.Codec,
+				mediaInfo.VideoStream.Profile,
+				mediaInfo.VideoStream.Level,
+				mediaInfo.Format.FormatName,
+			)
+		})
+		diag.runDetector("DetectProfileLevelConsistency", func() {
+			det.DetectProfileLevelConsistency(
+				mediaInfo.VideoStream.Codec,
+				mediaInfo.VideoStream.Profile,
+				mediaInfo.VideoStream.Level,
+				mediaInfo.VideoStream.Index,
+			)
+		})
+		diag.runDetector("DetectLevelCapability", func() {
+			det.DetectLevelCapability(
+				mediaInfo.VideoStream.Codec,
+				mediaInfo.VideoStream.Level,
+				mediaInfo.VideoStream.Width,
+				mediaInfo.VideoStream.Height,
+				videoFrameRate(mediaInfo.VideoStream),
+			)
+		})
 	}
 
 	result.Problems = det.GetProblems()
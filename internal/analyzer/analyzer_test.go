@@ -0,0 +1,90 @@
+package analyzer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+// stubFFprobe is a stand-in for ffprobe that reports a video stream whose
+// width is taken from the input's file name (e.g. "640.mp4"), so each input
+// yields distinguishable results
+const stubFFprobe = `#!/bin/sh
+for arg; do input="$arg"; done
+width=$(basename "$input" .mp4)
+case "$*" in
+*-show_packets*)
+	echo '{"packets":['
+	i=0
+	while [ $i -lt 49 ]; do
+		echo "{\"codec_type\":\"video\",\"stream_index\":0,\"pts_time\":\"$i\",\"dts_time\":\"$i\",\"size\":\"$width\"},"
+		i=$((i + 1))
+	done
+	echo "{\"codec_type\":\"video\",\"stream_index\":0,\"pts_time\":\"49\",\"dts_time\":\"49\",\"size\":\"$width\"}]}"
+	;;
+*-show_frames*)
+	echo '{"frames":[{"media_type":"video","stream_index":0,"key_frame":1,"pts_time":"0","pict_type":"I","width":'"$width"',"height":360}]}'
+	;;
+*)
+	echo '{"streams":[{"index":0,"codec_type":"video","codec_name":"h264","width":'"$width"',"height":360,"r_frame_rate":"25/1","avg_frame_rate":"25/1"}],"format":{"filename":"'"$input"'","format_name":"mov","duration":"50.0"}}'
+	;;
+esac
+`
+
+func TestAnalyzeWithDetailsConcurrent(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("stub ffprobe is a shell script")
+	}
+
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "ffprobe")
+	if err := os.WriteFile(binary, []byte(stubFFprobe), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	const inputs = 16
+	paths := make([]string, inputs)
+	for i := range paths {
+		paths[i] = filepath.Join(dir, fmt.Sprintf("%d.mp4", 320+i))
+		if err := os.WriteFile(paths[i], nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a := New(Options{
+		Timeout:        30,
+		ShowVideo:      true,
+		ShowFormat:     true,
+		AnalyzePackets: true,
+		AnalyzeFrames:  true,
+		FFprobePath:    binary,
+	})
+
+	var wg sync.WaitGroup
+	for round := 0; round < 4; round++ {
+		for i, path := range paths {
+			wg.Add(1)
+			go func(width int, path string) {
+				defer wg.Done()
+				result, err := a.AnalyzeWithDetails(path)
+				if err != nil {
+					t.Errorf("%s: %v", path, err)
+					return
+				}
+				if result.MediaInfo.Input != path {
+					t.Errorf("%s: got input %s", path, result.MediaInfo.Input)
+				}
+				if video := result.MediaInfo.VideoStream; video == nil || video.Width != width {
+					t.Errorf("%s: got video %+v, want width %d", path, video, width)
+				}
+				if len(result.Packets) != 50 || result.Packets[0].Size != width {
+					t.Errorf("%s: got %d packets, want 50 of %d bytes", path, len(result.Packets), width)
+				}
+			}(320+i, path)
+		}
+	}
+	wg.Wait()
+}
@@ -0,0 +1,102 @@
+package analyzer
+
+import "time"
+
+// Probe status values reported in Diagnostics
+const (
+	ProbeOK      = "ok"
+	ProbeFailed  = "failed"
+	ProbeSkipped = "skipped"
+)
+
+// Diagnostics records which probes and detectors actually ran during a
+// detailed analysis, making it visible when a probe failed and the
+// detectors depending on it were skipped
+type Diagnostics struct {
+	Probes    []ProbeStatus `json:"probes"`
+	Detectors []string      `json:"detectors"`
+	FromCache bool          `json:"from_cache,omitempty"`
+
+	// DetectorTimings holds per-detector wall time in milliseconds, only
+	// populated when detector profiling is enabled
+	DetectorTimings map[string]float64 `json:"detector_timings_ms,omitempty"`
+
+	profileDetectors bool
+}
+
+// ProbeStatus describes the outcome of a single ffprobe pass
+type ProbeStatus struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	Count  int    `json:"count,omitempty"`
+}
+
+func (d *Diagnostics) addProbe(name, status string, count int, err error) {
+	probe := ProbeStatus{Name: name, Status: status, Count: count}
+	if err != nil {
+		probe.Error = err.Error()
+	}
+	d.Probes = append(d.Probes, probe)
+}
+
+// runDetector executes a detector and records that it ran, along with how
+// long it took when detector profiling is enabled
+func (d *Diagnostics) runDetector(name string, detect func()) {
+	if !d.profileDetectors {
+		detect()
+		d.Detectors = append(d.Detectors, name)
+		return
+	}
+
+	start := time.Now()
+	detect()
+	elapsed := time.Since(start)
+
+	if d.DetectorTimings == nil {
+		d.DetectorTimings = make(map[string]float64)
+	}
+	d.DetectorTimings[name] += float64(elapsed.Microseconds()) / 1000
+	d.Detectors = append(d.Detectors, name)
+}
+
+// maxFFprobeLogMessages caps the distinct ffprobe log messages kept, since a
+// damaged file can make the demuxer complain about every packet
+const maxFFprobeLogMessages = 50
+
+// FFprobeLogMessage is a distinct line of ffprobe's own log output
+type FFprobeLogMessage struct {
+	Message string `json:"message"`
+	Count   int    `json:"count"` // Times logged by the probe that logged it most
+}
+
+// mergeFFprobeLog adds the log lines of one probe to log. The probes read
+// the same input, so a message logged by several of them keeps its highest
+// count rather than the sum.
+func mergeFFprobeLog(log []FFprobeLogMessage, lines []string) []FFprobeLogMessage {
+	counts := make(map[string]int)
+	var order []string
+	for _, line := range lines {
+		if counts[line] == 0 {
+			order = append(order, line)
+		}
+		counts[line]++
+	}
+
+	for _, message := range order {
+		found := false
+		for i := range log {
+			if log[i].Message == message {
+				if counts[message] > log[i].Count {
+					log[i].Count = counts[message]
+				}
+				found = true
+				break
+			}
+		}
+		if !found && len(log) < maxFFprobeLogMessages {
+			log = append(log, FFprobeLogMessage{Message: message, Count: counts[message]})
+		}
+	}
+	return log
+}
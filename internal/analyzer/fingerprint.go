@@ -0,0 +1,47 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/tomi/media-parser-cli/pkg/ffprobe"
+)
+
+// fingerprintVersion prefixes the fingerprint input. Bump it whenever the
+// composition changes so old and new fingerprints never collide.
+const fingerprintVersion = "mpfp1"
+
+// Fingerprint returns a stable SHA-256 over the technical parameters of the
+// input: container format, duration rounded to whole seconds and, per stream
+// in index order, the codec type, codec name and resolution (video) or
+// channel count and sample rate (audio). File bytes, tags, bitrates and
+// timestamps are deliberately excluded so re-encodes with identical
+// parameters hash the same.
+func Fingerprint(probeData *ffprobe.ProbeData) string {
+	var b strings.Builder
+	b.WriteString(fingerprintVersion)
+
+	formatName := ""
+	duration := 0.0
+	if probeData.Format != nil {
+		formatName = probeData.Format.FormatName
+		duration = probeData.Format.Duration
+	}
+	fmt.Fprintf(&b, "\nformat=%s\nduration=%d", formatName, int64(math.Round(duration)))
+
+	for _, stream := range probeData.Streams {
+		fmt.Fprintf(&b, "\n%s:%s", stream.CodecType, stream.CodecName)
+		switch stream.CodecType {
+		case "video":
+			fmt.Fprintf(&b, ":%dx%d", stream.Width, stream.Height)
+		case "audio":
+			fmt.Fprintf(&b, ":%dch@%s", stream.Channels, stream.SampleRate)
+		}
+	}
+
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:])
+}
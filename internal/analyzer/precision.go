@@ -0,0 +1,60 @@
+package analyzer
+
+import "math"
+
+// roundTo rounds v to the given number of decimal places
+func roundTo(v float64, precision int) float64 {
+	scale := math.Pow(10, float64(precision))
+	return math.Round(v*scale) / scale
+}
+
+// RoundTimes rounds duration and time fields to precision decimal places so
+// float noise such as 12.345999999 does not leak into reports. A negative
+// precision leaves values untouched.
+func (m *MediaInfo) RoundTimes(precision int) {
+	if m == nil || precision < 0 {
+		return
+	}
+	if m.Format != nil {
+		m.Format.Duration = roundTo(m.Format.Duration, precision)
+	}
+	if m.VideoStream != nil {
+		m.VideoStream.Duration = roundTo(m.VideoStream.Duration, precision)
+		m.VideoStream.StartTime = roundTo(m.VideoStream.StartTime, precision)
+	}
+	if m.AudioStream != nil {
+		m.AudioStream.Duration = roundTo(m.AudioStream.Duration, precision)
+		m.AudioStream.StartTime = roundTo(m.AudioStream.StartTime, precision)
+	}
+	for i := range m.VideoStreams {
+		m.VideoStreams[i].Duration = roundTo(m.VideoStreams[i].Duration, precision)
+		m.VideoStreams[i].StartTime = roundTo(m.VideoStreams[i].StartTime, precision)
+	}
+	for i := range m.AudioStreams {
+		m.AudioStreams[i].Duration = roundTo(m.AudioStreams[i].Duration, precision)
+		m.AudioStreams[i].StartTime = roundTo(m.AudioStreams[i].StartTime, precision)
+	}
+}
+
+// RoundTimes rounds the media info, problem timestamps and bitrate timeline
+// times to precision decimal places. Raw packet and frame timestamps are
+// kept at full precision. A negative precision leaves values untouched.
+func (d *DetailedAnalysis) RoundTimes(precision int) {
+	if precision < 0 {
+		return
+	}
+	d.MediaInfo.RoundTimes(precision)
+	for i := range d.Problems {
+		d.Problems[i].Timestamp = roundTo(d.Problems[i].Timestamp, precision)
+	}
+	for i := range d.Resolved {
+		d.Resolved[i].Timestamp = roundTo(d.Resolved[i].Timestamp, precision)
+	}
+	for i := range d.BitrateTimeline {
+		d.BitrateTimeline[i].Time = roundTo(d.BitrateTimeline[i].Time, precision)
+	}
+	if d.VideoStats != nil {
+		d.VideoStats.PeakTime = roundTo(d.VideoStats.PeakTime, precision)
+		d.VideoStats.MinTime = roundTo(d.VideoStats.MinTime, precision)
+	}
+}
@@ -0,0 +1,25 @@
+package analyzer
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// warnIfSlow starts a timer that prints a warning to stderr when a probe
+// phase is still running after SlowWarningThreshold of the timeout has
+// elapsed. The returned function stops the timer and must be called when the
+// phase finishes.
+func (a *Analyzer) warnIfSlow(phase string) func() {
+	if a.options.SlowWarningThreshold <= 0 || a.options.Timeout <= 0 {
+		return func() {}
+	}
+
+	timeout := time.Duration(a.options.Timeout) * time.Second
+	after := time.Duration(float64(timeout) * a.options.SlowWarningThreshold)
+	timer := time.AfterFunc(after, func() {
+		fmt.Fprintf(os.Stderr, "Warning: %s probe still running after %s (timeout %s); the input may be stalled\n",
+			phase, after.Round(time.Second), timeout)
+	})
+	return func() { timer.Stop() }
+}
@@ -0,0 +1,71 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/tomi/media-parser-cli/pkg/ffprobe"
+)
+
+// analyzeStdin runs a detailed analysis of standard input. Packet and frame
+// analysis each need their own ffprobe pass, but stdin can only be read
+// once, so it is first buffered to a temporary file that every pass reads.
+// The result reports the input as "-" rather than the temporary path.
+func (a *Analyzer) analyzeStdin(ctx context.Context) (*DetailedAnalysis, error) {
+	spool, err := a.spoolStdin("multi-pass analysis")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(spool)
+
+	result, err := a.analyzeWithDetails(ctx, spool)
+	if err != nil {
+		return nil, err
+	}
+	result.MediaInfo.Input = ffprobe.StdinInput
+	result.MediaInfo.AbsolutePath = ""
+	return result, nil
+}
+
+// analyzeStdinHashed runs a basic analysis of standard input when its hash
+// is wanted. ffprobe stops reading once it has found the streams, so stdin
+// is buffered in full first and the buffered copy is probed and hashed.
+func (a *Analyzer) analyzeStdinHashed(ctx context.Context) (*MediaInfo, error) {
+	spool, err := a.spoolStdin("hashing")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(spool)
+
+	info, err := a.AnalyzeContext(ctx, spool)
+	if err != nil {
+		return nil, err
+	}
+	info.Input = ffprobe.StdinInput
+	info.AbsolutePath = ""
+	return info, nil
+}
+
+// spoolStdin copies standard input to a temporary file and returns its
+// path. The caller removes the file.
+func (a *Analyzer) spoolStdin(purpose string) (string, error) {
+	spool, err := os.CreateTemp("", "media-parser-stdin-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to buffer stdin: %w", err)
+	}
+
+	_, err = io.Copy(spool, os.Stdin)
+	if closeErr := spool.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(spool.Name())
+		return "", fmt.Errorf("failed to buffer stdin: %w", err)
+	}
+	if a.options.Verbose {
+		fmt.Fprintf(os.Stderr, "Buffered stdin to %s for %s\n", spool.Name(), purpose)
+	}
+	return spool.Name(), nil
+}
@@ -0,0 +1,51 @@
+package analyzer
+
+import "github.com/tomi/media-parser-cli/internal/detector"
+
+// VideoStats are bitrate statistics over the 1 second windows of the video
+// stream's packets, for bandwidth planning where the container's nominal
+// bitrate is missing or optimistic
+type VideoStats struct {
+	AvgBitrate  int64   `json:"avg_bitrate"`  // Mean over the windows, bits per second
+	PeakBitrate int64   `json:"peak_bitrate"` // Highest window
+	PeakTime    float64 `json:"peak_time"`    // Start of the peak window in seconds
+	MinBitrate  int64   `json:"min_bitrate"`  // Lowest window
+	MinTime     float64 `json:"min_time"`     // Start of the lowest window in seconds
+	Windows     int     `json:"windows"`      // Number of windows the statistics cover
+}
+
+// videoStats computes VideoStats from the "video" series of a 1 second
+// bitrate timeline. The last window usually covers only part of a second,
+// so it is left out when there are others. Returns nil when the timeline
+// has no video series.
+func videoStats(timeline []detector.BitratePoint) *VideoStats {
+	var points []detector.BitratePoint
+	for _, point := range timeline {
+		if point.Type == "video" {
+			points = append(points, point)
+		}
+	}
+	if len(points) == 0 {
+		return nil
+	}
+	if len(points) > 1 {
+		points = points[:len(points)-1]
+	}
+
+	stats := &VideoStats{Windows: len(points)}
+	peak, low := points[0], points[0]
+	var total float64
+	for _, point := range points {
+		total += point.Bitrate
+		if point.Bitrate > peak.Bitrate {
+			peak = point
+		}
+		if point.Bitrate < low.Bitrate {
+			low = point
+		}
+	}
+	stats.AvgBitrate = int64(total / float64(len(points)))
+	stats.PeakBitrate, stats.PeakTime = int64(peak.Bitrate), peak.Time
+	stats.MinBitrate, stats.MinTime = int64(low.Bitrate), low.Time
+	return stats
+}
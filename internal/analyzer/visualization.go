@@ -0,0 +1,106 @@
+package analyzer
+
+import (
+	"strings"
+
+	"github.com/tomi/media-parser-cli/internal/detector"
+)
+
+// maxTimelineEntries caps the frame timeline; longer inputs are sampled
+const maxTimelineEntries = 1000
+
+// FrameVisualization describes the frame-type mix, GOP structure and a
+// sampled frame timeline of the video frames of an analysis
+type FrameVisualization struct {
+	TotalFrames  int                  `json:"total_frames"`
+	Duration     float64              `json:"duration"`
+	FrameTypes   map[string]int       `json:"frame_types"`
+	GOPStructure []detector.GOPInfo   `json:"gop_structure"`
+	Timeline     []FrameTimelineEntry `json:"timeline"`
+}
+
+// FrameTimelineEntry is one sampled video frame of the timeline
+type FrameTimelineEntry struct {
+	Time      float64 `json:"time"`
+	FrameType string  `json:"frame_type"`
+	Size      int     `json:"size"`
+	KeyFrame  bool    `json:"key_frame"`
+}
+
+// GenerateFrameVisualization builds the frame visualization of frames. It
+// returns nil when there are no frames.
+func GenerateFrameVisualization(frames []FrameData) *FrameVisualization {
+	if len(frames) == 0 {
+		return nil
+	}
+
+	viz := &FrameVisualization{
+		TotalFrames: len(frames),
+		FrameTypes:  make(map[string]int),
+		Timeline:    make([]FrameTimelineEntry, 0),
+	}
+
+	// Find video frames only
+	videoFrames := make([]FrameData, 0)
+	for _, frame := range frames {
+		if strings.ToLower(frame.MediaType) == "video" {
+			videoFrames = append(videoFrames, frame)
+		}
+	}
+
+	if len(videoFrames) == 0 {
+		return viz
+	}
+
+	// Count frame types and build timeline, sampling every N frames for
+	// large files
+	sampleRate := len(videoFrames) / maxTimelineEntries
+	if sampleRate < 1 {
+		sampleRate = 1
+	}
+	for i, frame := range videoFrames {
+		if frame.PictType != "" {
+			viz.FrameTypes[frame.PictType]++
+		}
+		if i%sampleRate == 0 {
+			viz.Timeline = append(viz.Timeline, FrameTimelineEntry{
+				Time:      frame.PTS,
+				FrameType: frame.PictType,
+				Size:      frame.Size,
+				KeyFrame:  frame.KeyFrame,
+			})
+		}
+	}
+
+	// Keyframes start a new GOP
+	viz.GOPStructure = detector.BuildGOPs(toFrameInfos(videoFrames))
+
+	viz.Duration = videoFrames[len(videoFrames)-1].PTS
+
+	return viz
+}
+
+// toFrameInfos converts frames to the detector's frame representation
+func toFrameInfos(frames []FrameData) []detector.FrameInfo {
+	frameInfos := make([]detector.FrameInfo, 0, len(frames))
+	for _, f := range frames {
+		frameInfos = append(frameInfos, detector.FrameInfo{
+			MediaType:       f.MediaType,
+			StreamIndex:     f.StreamIndex,
+			KeyFrame:        f.KeyFrame,
+			PTS:             f.PTS,
+			DTS:             f.DTS,
+			Duration:        f.Duration,
+			Size:            f.Size,
+			PictType:        f.PictType,
+			CodedNumber:     f.CodedNumber,
+			RepeatPict:      f.RepeatPict,
+			InterlacedFrame: f.InterlacedFrame,
+			TopFieldFirst:   f.TopFieldFirst,
+			Width:           f.Width,
+			Height:          f.Height,
+			AttachedPic:     f.AttachedPic,
+		})
+	}
+	return frameInfos
+}
@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Cache stores analysis results on disk so repeated runs against the same
+// unchanged input can skip ffprobe entirely.
+//
+// Entries are keyed by the SHA-256 of:
+//   - the absolute input path
+//   - the input's modification time (nanoseconds)
+//   - the input's size in bytes
+//   - the SHA-256 of the JSON-encoded analysis options
+//
+// Any change to the file (touch, rewrite, truncate) or to the options
+// produces a different key, so stale entries are never returned. Remote
+// inputs (URLs) and standard input ("-") are never cached because they have
+// no stable mtime/size.
+type Cache struct {
+	dir string
+}
+
+func New(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// Key computes the cache key for input analyzed with options. The second
+// return value is false when the input cannot be cached.
+func Key(input string, options interface{}) (string, bool) {
+	if strings.Contains(input, "://") || input == "-" {
+		return "", false
+	}
+
+	absPath, err := filepath.Abs(input)
+	if err != nil {
+		return "", false
+	}
+	stat, err := os.Stat(absPath)
+	if err != nil || !stat.Mode().IsRegular() {
+		return "", false
+	}
+
+	optionsJSON, err := json.Marshal(options)
+	if err != nil {
+		return "", false
+	}
+	optionsHash := sha256.Sum256(optionsJSON)
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%d\n%d\n%x", absPath, stat.ModTime().UnixNano(), stat.Size(), optionsHash)
+	return hex.EncodeToString(h.Sum(nil)), true
+}
+
+// Load reads the entry for key into v. It reports false on a cache miss.
+func (c *Cache) Load(key string, v interface{}) (bool, error) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("corrupt cache entry %s: %w", key, err)
+	}
+	return true, nil
+}
+
+// Store writes v as the entry for key
+func (c *Cache) Store(key string, v interface{}) error {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so concurrent readers never see a
+	// partially written entry
+	tmp, err := os.CreateTemp(c.dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path(key))
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
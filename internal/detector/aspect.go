@@ -0,0 +1,88 @@
+package detector
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tomi/media-parser-cli/pkg/ffprobe"
+)
+
+// parseRatio parses an ffprobe aspect ratio such as "16:9" or "40:33" with
+// ffprobe.ParseRational. The undefined ratio ffprobe reports ("0:1") is
+// returned as 0 with ok set, so callers can tell it from a malformed one.
+func parseRatio(ratio string) (value float64, ok bool) {
+	value, err := ffprobe.ParseRational(strings.Replace(strings.TrimSpace(ratio), ":", "/", 1))
+	if err != nil || value < 0 {
+		return 0, false
+	}
+	return value, true
+}
+
+// anamorphicProneSizes are coded widths/heights of SD formats that are
+// usually stored with non-square pixels and therefore need a SAR
+var anamorphicProneSizes = map[[2]int]bool{
+	{720, 480}: true,
+	{720, 576}: true,
+	{704, 480}: true,
+	{704, 576}: true,
+	{480, 480}: true,
+	{480, 576}: true,
+	{352, 480}: true,
+	{352, 576}: true,
+}
+
+// DetectUndefinedSAR flags video whose sample aspect ratio is undefined
+// ("0:1"), or missing on content that normally relies on one, which leaves
+// the display aspect up to each player
+func (d *Detector) DetectUndefinedSAR(sar string, width, height, streamIndex int) {
+	if width <= 0 || height <= 0 {
+		return
+	}
+
+	ratio, ok := parseRatio(sar)
+	undefined := ok && ratio == 0
+	missing := sar == "" && anamorphicProneSizes[[2]int{width, height}]
+	if !undefined && !missing {
+		return
+	}
+
+	raw := sar
+	if raw == "" {
+		raw = "(empty)"
+	}
+
+	d.addProblem(Problem{
+		Severity:    SeverityWarning,
+		Category:    CategoryResolution,
+		Code:        "UNDEFINED_SAR",
+		Message:     fmt.Sprintf("Sample aspect ratio is undefined for %dx%d video", width, height),
+		Details:     fmt.Sprintf("Raw SAR: %s", raw),
+		Suggestion:  "Set the SAR explicitly (e.g. 1:1 for square pixels) so players display the intended aspect ratio",
+		StreamIndex: streamIndex,
+	})
+}
+
+// DetectAnamorphicForProfile flags video with non-square pixels when the
+// profile targets square-pixel displays (web, mobile), where players that
+// ignore the SAR show the picture stretched. Undefined SARs are left to
+// DetectUndefinedSAR.
+func (d *Detector) DetectAnamorphicForProfile(profile *Profile, sar string, width, height, streamIndex int) {
+	if profile == nil || !profile.SquarePixels {
+		return
+	}
+	ratio, ok := parseRatio(sar)
+	if !ok || ratio == 0 || ratio == 1 {
+		return
+	}
+
+	displayWidth := int(float64(width) * ratio)
+	d.addProblem(Problem{
+		Severity:    SeverityWarning,
+		Category:    CategoryResolution,
+		Code:        "ANAMORPHIC_FOR_SQUARE_DISPLAY",
+		Message:     fmt.Sprintf("Anamorphic video does not suit the %s profile's square-pixel displays", profile.Name),
+		Details:     fmt.Sprintf("SAR: %s, coded %dx%d (displays as %dx%d), profile: %s", sar, width, height, displayWidth, height, profile.Name),
+		Suggestion:  fmt.Sprintf("Re-encode with square pixels, e.g. ffmpeg -vf scale=%d:%d,setsar=1", displayWidth-displayWidth%2, height),
+		StreamIndex: streamIndex,
+	})
+}
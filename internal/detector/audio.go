@@ -0,0 +1,43 @@
+package detector
+
+import "fmt"
+
+// DetectAudioTruncation sums the packet durations of an audio stream and
+// flags AUDIO_TRUNCATED when they cover significantly less time than the
+// duration the stream declares. Packets must be the complete packet list,
+// not a truncated sample.
+func (d *Detector) DetectAudioTruncation(packets []PacketInfo, streamIndex int, declaredDuration float64) {
+	if declaredDuration <= 0 {
+		return
+	}
+
+	summed := 0.0
+	count := 0
+	for _, packet := range packets {
+		if packet.StreamIndex != streamIndex {
+			continue
+		}
+		summed += packet.Duration
+		count++
+	}
+	if count == 0 || summed <= 0 {
+		return
+	}
+
+	// Allow for rounding and a trailing partial frame before flagging
+	missing := declaredDuration - summed
+	if missing < 0.5 || summed >= declaredDuration*0.98 {
+		return
+	}
+
+	d.addProblem(Problem{
+		Severity:    SeverityWarning,
+		Category:    CategoryAudio,
+		Code:        "AUDIO_TRUNCATED",
+		Message:     fmt.Sprintf("Audio packets cover %.2fs less than the declared duration", missing),
+		Details:     fmt.Sprintf("Declared: %.3fs, summed from %d packets: %.3fs (%.1f%%)", declaredDuration, count, summed, summed/declaredDuration*100),
+		Suggestion:  "The audio track may be truncated or only partially muxed; check the source and re-mux",
+		Timestamp:   summed,
+		StreamIndex: streamIndex,
+	})
+}
@@ -0,0 +1,113 @@
+package detector
+
+import (
+	"fmt"
+	"math"
+)
+
+// timestampOffsets returns, for each frame, how far its PTS has moved from
+// the time implied by the durations of the frames before it. A stream whose
+// timestamps follow its content stays at zero. Returns nil when any frame
+// lacks a duration.
+func timestampOffsets(frames []FrameInfo) []float64 {
+	offsets := make([]float64, len(frames))
+	var content float64
+	for i, frame := range frames {
+		if frame.Duration <= 0 {
+			return nil
+		}
+		offsets[i] = (frame.PTS - frames[0].PTS) - content
+		content += frame.Duration
+	}
+	return offsets
+}
+
+// DetectAVSync compares the timestamp progression of a video stream against
+// an audio stream and flags when the audio drifts away from the video by more
+// than the configured AVSyncThreshold (100ms by default). The initial offset
+// between the streams is ignored; only drift that accumulates during playback
+// is reported. Frames must be in presentation order and belong to a single
+// stream each.
+func (d *Detector) DetectAVSync(videoFrames, audioFrames []FrameInfo) {
+	if len(videoFrames) < 2 || len(audioFrames) < 2 {
+		return
+	}
+
+	videoOffsets := timestampOffsets(videoFrames)
+	audioOffsets := timestampOffsets(audioFrames)
+	if videoOffsets == nil || audioOffsets == nil {
+		return
+	}
+
+	v := 0
+	crossed := -1
+	var crossedDrift, maxDrift float64
+	for i, audio := range audioFrames {
+		// Advance to the last video frame presented at or before this audio frame
+		for v+1 < len(videoFrames) && videoFrames[v+1].PTS <= audio.PTS {
+			v++
+		}
+		drift := audioOffsets[i] - videoOffsets[v]
+		if math.Abs(drift) > math.Abs(maxDrift) {
+			maxDrift = drift
+		}
+		if crossed < 0 && math.Abs(drift) > d.config.AVSyncThreshold {
+			crossed = i
+			crossedDrift = drift
+		}
+	}
+
+	if crossed < 0 {
+		return
+	}
+
+	d.addProblem(Problem{
+		Severity:    SeverityWarning,
+		Category:    CategoryTimestamp,
+		Code:        "AV_SYNC_DRIFT",
+		Message:     fmt.Sprintf("Audio drifts out of sync with video by more than %.0fms", d.config.AVSyncThreshold*1000),
+		Details:     fmt.Sprintf("Drift %+.0fms at %.3fs, largest drift %+.0fms (positive means audio is late)", crossedDrift*1000, audioFrames[crossed].PTS, maxDrift*1000),
+		Timestamp:   audioFrames[crossed].PTS,
+		StreamIndex: audioFrames[crossed].StreamIndex,
+		Suggestion:  "Resync the audio (e.g. ffmpeg -af aresample=async=1) or re-mux from the original recording",
+	})
+}
+
+// TrackStart is the container start time of an audio track
+type TrackStart struct {
+	Index     int
+	Language  string
+	StartTime float64 // Seconds, from ffprobe start_time
+}
+
+// DetectAudioTrackDelays compares each audio track's start time with the
+// video's and flags tracks offset by more than the configured
+// AudioTrackDelayTolerance (40ms by default). Unlike drift, a start offset
+// is constant, so in multi-language deliverables it shows up as one
+// language being out of sync while the others are fine.
+func (d *Detector) DetectAudioTrackDelays(videoStart float64, tracks []TrackStart) {
+	for _, track := range tracks {
+		delta := track.StartTime - videoStart
+		if math.Abs(delta) <= d.config.AudioTrackDelayTolerance {
+			continue
+		}
+
+		language := track.Language
+		if language == "" {
+			language = "und"
+		}
+		direction := "late"
+		if delta < 0 {
+			direction = "early"
+		}
+		d.addProblem(Problem{
+			Severity:    SeverityWarning,
+			Category:    CategoryTimestamp,
+			Code:        "AUDIO_TRACK_DELAY_MISMATCH",
+			Message:     fmt.Sprintf("Audio track %d (%s) starts %.0fms %s relative to the video", track.Index, language, math.Abs(delta)*1000, direction),
+			Details:     fmt.Sprintf("Video start: %.6fs, audio start: %.6fs, delta: %+.3fs (tolerance %.3fs)", videoStart, track.StartTime, delta, d.config.AudioTrackDelayTolerance),
+			Suggestion:  fmt.Sprintf("Shift the track by %+.3fs to match the video (e.g. ffmpeg -itsoffset) or fix the edit list", -delta),
+			StreamIndex: track.Index,
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package detector
+
+import "fmt"
+
+// ProblemKey identifies a problem by its code and location so the same issue
+// can be matched across runs. Timestamps are compared at millisecond
+// resolution to tolerate float formatting differences in stored results.
+func ProblemKey(p Problem) string {
+	return fmt.Sprintf("%s|%d|%.3f", p.Code, p.StreamIndex, p.Timestamp)
+}
+
+// DiffProblems compares current problems against a baseline run. It returns
+// the problems that are new in current and the baseline problems that no
+// longer occur.
+func DiffProblems(current, baseline []Problem) (added, resolved []Problem) {
+	baselineKeys := make(map[string]bool, len(baseline))
+	for _, p := range baseline {
+		baselineKeys[ProblemKey(p)] = true
+	}
+	currentKeys := make(map[string]bool, len(current))
+	for _, p := range current {
+		currentKeys[ProblemKey(p)] = true
+	}
+
+	for _, p := range current {
+		if !baselineKeys[ProblemKey(p)] {
+			added = append(added, p)
+		}
+	}
+	for _, p := range baseline {
+		if !currentKeys[ProblemKey(p)] {
+			resolved = append(resolved, p)
+		}
+	}
+	return added, resolved
+}
@@ -0,0 +1,279 @@
+package detector
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestGenerateBitrateTimeline(t *testing.T) {
+	tests := []struct {
+		name    string
+		packets []PacketInfo
+		want    []BitratePoint
+	}{
+		{
+			name: "in order",
+			packets: []PacketInfo{
+				{PTS: 10.0, Size: 100},
+				{PTS: 10.5, Size: 100},
+				{PTS: 11.2, Size: 50},
+			},
+			want: []BitratePoint{
+				{Time: 10, Bitrate: 1600, Type: "total"},
+				{Time: 11, Bitrate: 400, Type: "total"},
+			},
+		},
+		{
+			name: "out of order",
+			packets: []PacketInfo{
+				{PTS: 10.5, Size: 100},
+				{PTS: 11.5, Size: 50},
+				{PTS: 10.0, Size: 100}, // Earliest packet arrives last
+				{PTS: 10.9, Size: 25},
+			},
+			want: []BitratePoint{
+				{Time: 10, Bitrate: 1800, Type: "total"},
+				{Time: 11, Bitrate: 400, Type: "total"},
+			},
+		},
+		{
+			name: "gap",
+			packets: []PacketInfo{
+				{PTS: 0, Size: 100},
+				{PTS: 3.5, Size: 100},
+			},
+			want: []BitratePoint{
+				{Time: 0, Bitrate: 800, Type: "total"},
+				{Time: 1, Bitrate: 0, Type: "total"},
+				{Time: 2, Bitrate: 0, Type: "total"},
+				{Time: 3, Bitrate: 800, Type: "total"},
+			},
+		},
+		{
+			name: "window boundary",
+			packets: []PacketInfo{
+				{PTS: 0, Size: 100},
+				{PTS: 1, Size: 10}, // Starts the second window
+				{PTS: 1.5, Size: 10},
+			},
+			want: []BitratePoint{
+				{Time: 0, Bitrate: 800, Type: "total"},
+				{Time: 1, Bitrate: 160, Type: "total"},
+			},
+		},
+		{
+			name: "missing PTS",
+			packets: []PacketInfo{
+				{PTS: 0, Size: 999, PTSMissing: true},
+				{PTS: 3600, Size: 100},
+				{PTS: 3600.5, Size: 100},
+			},
+			want: []BitratePoint{
+				{Time: 3600, Bitrate: 1600, Type: "total"},
+			},
+		},
+		{
+			name: "bogus PTS",
+			packets: []PacketInfo{
+				{PTS: 0, Size: 100},
+				{PTS: 1e9, Size: 100},
+			},
+			want: []BitratePoint{
+				{Time: 0, Bitrate: 800, Type: "total"},
+			},
+		},
+		{
+			name: "no PTS at all",
+			packets: []PacketInfo{
+				{Size: 100, PTSMissing: true},
+			},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := GenerateBitrateTimeline(tt.packets, 1.0)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateBitrateTimelinePerStream(t *testing.T) {
+	packets := []PacketInfo{
+		{PTS: 0.5, Size: 100, CodecType: "audio"},
+		{PTS: 1.0, Size: 200, CodecType: "video"}, // On the boundary
+		{PTS: 0.0, Size: 300, CodecType: "video"}, // Out of order
+		{PTS: 3.2, Size: 50, CodecType: "audio"},  // After a gap
+		{PTS: 2.0, Size: 400, CodecType: "cover_art"},
+	}
+
+	want := []BitratePoint{
+		{Time: 0, Bitrate: 3200, Type: "total"},
+		{Time: 1, Bitrate: 1600, Type: "total"},
+		{Time: 2, Bitrate: 3200, Type: "total"},
+		{Time: 3, Bitrate: 400, Type: "total"},
+		// Video and audio share the total's windows, starting at 0
+		{Time: 0, Bitrate: 2400, Type: "video"},
+		{Time: 1, Bitrate: 1600, Type: "video"},
+		{Time: 0, Bitrate: 800, Type: "audio"},
+		{Time: 1, Bitrate: 0, Type: "audio"},
+		{Time: 2, Bitrate: 0, Type: "audio"},
+		{Time: 3, Bitrate: 400, Type: "audio"},
+	}
+	if got := GenerateBitrateTimelinePerStream(packets, 1.0); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestDetectBitrateVariations(t *testing.T) {
+	tests := []struct {
+		name    string
+		packets []PacketInfo
+		spikes  []float64 // Timestamps of the expected BITRATE_SPIKE problems
+		highCV  bool
+	}{
+		{
+			name:    "constant, out of order",
+			packets: steadyPackets(10, 5, 1000, func(i int) int { return (i*7 + 3) % 50 }),
+		},
+		{
+			name:    "gap is not a variation",
+			packets: append(steadyPackets(0, 5, 1000, nil), steadyPackets(20, 5, 1000, nil)...),
+		},
+		{
+			name: "spike on a window boundary",
+			packets: append(steadyPackets(0, 8, 1000, nil),
+				PacketInfo{PTS: 4, Size: 100000}),
+			spikes: []float64{4},
+			highCV: true,
+		},
+		{
+			name: "missing PTS does not move the windows",
+			packets: append(steadyPackets(3600, 5, 1000, nil),
+				PacketInfo{Size: 1000, PTSMissing: true}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := New()
+			d.DetectBitrateVariations(tt.packets)
+
+			var spikes []float64
+			for _, problem := range d.GetProblems() {
+				if problem.Code == "BITRATE_SPIKE" {
+					spikes = append(spikes, problem.Timestamp)
+				}
+			}
+			if !reflect.DeepEqual(spikes, tt.spikes) {
+				t.Errorf("got spikes at %v, want %v", spikes, tt.spikes)
+			}
+			if got := countCode(d.GetProblems(), "BITRATE_HIGH_VARIANCE") > 0; got != tt.highCV {
+				t.Errorf("got BITRATE_HIGH_VARIANCE %t, want %t", got, tt.highCV)
+			}
+		})
+	}
+}
+
+// steadyPackets returns 10 packets per second of size bytes for seconds
+// seconds from start. order, when set, maps a position to the index of the
+// packet placed there.
+func steadyPackets(start float64, seconds, size int, order func(int) int) []PacketInfo {
+	packets := make([]PacketInfo, seconds*10)
+	for i := range packets {
+		j := i
+		if order != nil {
+			j = order(i)
+		}
+		packets[i] = PacketInfo{PTS: start + float64(j)*0.1, Size: size}
+	}
+	return packets
+}
+
+func TestMeasuredStreamBitrate(t *testing.T) {
+	tests := []struct {
+		name        string
+		packets     []PacketInfo
+		wantBitrate float64
+		wantSpan    float64
+	}{
+		// Duration is 0, so the span ends at the last packet's PTS
+		{
+			name:        "steady",
+			packets:     steadyPackets(3600, 5, 1000, nil),
+			wantBitrate: 50 * 1000 * 8 / 4.9,
+			wantSpan:    4.9,
+		},
+		{
+			name: "missing PTS",
+			packets: append(steadyPackets(3600, 5, 1000, nil),
+				PacketInfo{Size: 1000, PTSMissing: true}),
+			wantBitrate: 50 * 1000 * 8 / 4.9,
+			wantSpan:    4.9,
+		},
+		{
+			name:    "no PTS at all",
+			packets: []PacketInfo{{Size: 1000, PTSMissing: true}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bitrate, span := MeasuredStreamBitrate(tt.packets, 0)
+			if math.Abs(span-tt.wantSpan) > 1e-6 || math.Abs(bitrate-tt.wantBitrate) > 1e-6 {
+				t.Errorf("got %v bits/s over %vs, want %v bits/s over %vs", bitrate, span, tt.wantBitrate, tt.wantSpan)
+			}
+		})
+	}
+}
+
+func TestPeakRollingBitrate(t *testing.T) {
+	tests := []struct {
+		name    string
+		packets []PacketInfo
+		want    float64
+	}{
+		{
+			name:    "steady",
+			packets: steadyPackets(3600, 5, 1000, nil),
+			want:    80000,
+		},
+		{
+			name: "missing PTS",
+			packets: append(steadyPackets(3600, 5, 1000, nil),
+				PacketInfo{Size: 1000, PTSMissing: true}),
+			want: 80000,
+		},
+		{
+			name: "burst",
+			packets: append(steadyPackets(0, 5, 1000, nil),
+				PacketInfo{PTS: 2.05, Size: 10000}),
+			want: 160000,
+		},
+		{
+			name: "missing PTS, shorter than the window",
+			packets: []PacketInfo{
+				{PTS: 3600, Size: 1000},
+				{PTS: 3600.5, Size: 1000},
+				{Size: 1000, PTSMissing: true},
+			},
+			want: 32000,
+		},
+		{
+			name:    "no PTS at all",
+			packets: []PacketInfo{{Size: 1000, PTSMissing: true}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PeakRollingBitrate(tt.packets, 1.0); math.Abs(got-tt.want) > 1e-6 {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package detector
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// deprecatedCodecs maps obsolete ffprobe codec names to a description and a
+// modern replacement suggestion
+var deprecatedCodecs = map[string]struct {
+	name        string
+	replacement string
+}{
+	"rv10":       {"RealVideo 1.0", "H.264"},
+	"rv20":       {"RealVideo 2.0", "H.264"},
+	"rv30":       {"RealVideo 3.0", "H.264"},
+	"rv40":       {"RealVideo 4.0", "H.264"},
+	"wmv1":       {"Windows Media Video 7", "H.264"},
+	"wmv2":       {"Windows Media Video 8", "H.264"},
+	"wmv3":       {"Windows Media Video 9", "H.264"},
+	"vc1":        {"VC-1", "H.264 or HEVC"},
+	"flv1":       {"Sorenson Spark (FLV1)", "H.264"},
+	"svq1":       {"Sorenson Video 1", "H.264"},
+	"svq3":       {"Sorenson Video 3", "H.264"},
+	"h263":       {"H.263", "H.264"},
+	"msmpeg4v1":  {"MS MPEG-4 v1", "H.264"},
+	"msmpeg4v2":  {"MS MPEG-4 v2", "H.264"},
+	"msmpeg4v3":  {"MS MPEG-4 v3 (DivX 3)", "H.264"},
+	"mpeg4":      {"MPEG-4 Part 2 (DivX/Xvid)", "H.264 or HEVC"},
+	"vp6":        {"On2 VP6", "H.264 or VP9"},
+	"vp6f":       {"On2 VP6 (Flash)", "H.264 or VP9"},
+	"theora":     {"Theora", "VP9 or AV1"},
+	"cinepak":    {"Cinepak", "H.264"},
+	"indeo3":     {"Intel Indeo 3", "H.264"},
+	"indeo5":     {"Intel Indeo 5", "H.264"},
+	"cook":       {"RealAudio Cook", "AAC"},
+	"wmav1":      {"Windows Media Audio 1", "AAC"},
+	"wmav2":      {"Windows Media Audio 2", "AAC"},
+	"nellymoser": {"Nellymoser Asao", "AAC"},
+	"adpcm_swf":  {"ADPCM (Flash)", "AAC"},
+}
+
+// DetectDeprecatedCodec flags codecs that are obsolete and poorly supported
+// on modern platforms
+func (d *Detector) DetectDeprecatedCodec(codec string, streamIndex int) {
+	info, ok := deprecatedCodecs[strings.ToLower(codec)]
+	if !ok {
+		return
+	}
+
+	d.addProblem(Problem{
+		Severity:    SeverityWarning,
+		Category:    CategoryCodec,
+		Code:        "DEPRECATED_CODEC",
+		Message:     fmt.Sprintf("Stream %d uses obsolete codec %s (%s)", streamIndex, codec, info.name),
+		Details:     fmt.Sprintf("Suggested replacement: %s", info.replacement),
+		Suggestion:  fmt.Sprintf("Transcode to %s for playback on modern devices", info.replacement),
+		StreamIndex: streamIndex,
+	})
+}
+
+// oldEncoderRules recognize encoder versions with known bugs. Each pattern
+// captures a version number that is compared against minVersion.
+var oldEncoderRules = []struct {
+	pattern    *regexp.Regexp
+	minVersion int
+	note       string
+}{
+	{regexp.MustCompile(`^Lav[fc](\d+)\.`), 57, "libavformat/libavcodec before 57 (FFmpeg < 3.0) wrote broken edit lists and timestamps in some muxers"},
+	{regexp.MustCompile(`x264 - core (\d+)`), 148, "x264 builds before core 148 had rate-control and VBV fixes missing"},
+	{regexp.MustCompile(`^HandBrake 0\.(\d+)`), 100, "HandBrake 0.x releases predate current container and audio fixes"},
+}
+
+// DetectOldEncoder notes encoder versions that are known to produce files
+// with muxing or encoding bugs
+func (d *Detector) DetectOldEncoder(encoder string, streamIndex int) {
+	for _, rule := range oldEncoderRules {
+		match := rule.pattern.FindStringSubmatch(encoder)
+		if match == nil {
+			continue
+		}
+		version, err := strconv.Atoi(match[1])
+		if err != nil || version >= rule.minVersion {
+			continue
+		}
+
+		d.addProblem(Problem{
+			Severity:    SeverityInfo,
+			Category:    CategoryCodec,
+			Code:        "OLD_ENCODER_VERSION",
+			Message:     fmt.Sprintf("File was produced by an old encoder: %s", encoder),
+			Details:     rule.note,
+			Suggestion:  "If you see playback issues, re-encode or remux with a current toolchain",
+			StreamIndex: streamIndex,
+		})
+		return
+	}
+}
+
+// DetectHEVCTag flags HEVC in MP4/MOV tagged hev1 (parameter sets in-band),
+// which Apple devices and Safari refuse to play; they require hvc1
+func (d *Detector) DetectHEVCTag(codec, codecTag, container string, streamIndex int) {
+	if !strings.EqualFold(codec, "hevc") || !strings.EqualFold(codecTag, "hev1") {
+		return
+	}
+	if !strings.Contains(container, "mp4") && !strings.Contains(container, "mov") {
+		return
+	}
+
+	d.addProblem(Problem{
+		Severity:    SeverityWarning,
+		Category:    CategoryCompatibility,
+		Code:        "HEVC_HEV1_TAG",
+		Message:     "HEVC stream is tagged hev1, which Apple devices and Safari do not play",
+		Details:     fmt.Sprintf("Codec tag: %s", codecTag),
+		Suggestion:  "Re-mux with the hvc1 tag (ffmpeg -c copy -tag:v hvc1)",
+		StreamIndex: streamIndex,
+	})
+}
+
+// losslessCodecs are ffprobe codec names that compress without loss. Codecs
+// not listed here are treated as lossy.
+var losslessCodecs = map[string]bool{
+	// Audio
+	"flac":       true,
+	"alac":       true,
+	"truehd":     true,
+	"mlp":        true,
+	"wavpack":    true,
+	"tta":        true,
+	"ape":        true,
+	"pcm_s16le":  true,
+	"pcm_s16be":  true,
+	"pcm_s24le":  true,
+	"pcm_s24be":  true,
+	"pcm_s32le":  true,
+	"pcm_s32be":  true,
+	"pcm_f32le":  true,
+	"pcm_f32be":  true,
+	"pcm_f64le":  true,
+	"pcm_u8":     true,
+	"pcm_bluray": true,
+	"pcm_dvd":    true,
+	// Video
+	"ffv1":     true,
+	"huffyuv":  true,
+	"ffvhuff":  true,
+	"utvideo":  true,
+	"magicyuv": true,
+	"rawvideo": true,
+	"png":      true,
+}
+
+// IsLosslessCodec reports whether the codec compresses without loss
+func IsLosslessCodec(codec string) bool {
+	return losslessCodecs[strings.ToLower(codec)]
+}
+
+// lowBitrateVideo is the video bitrate below which pairing it with lossless
+// audio is considered a mismatch
+const lowBitrateVideo = 8_000_000
+
+// DetectQualityTierMismatch flags lossless audio delivered alongside
+// low-bitrate lossy video, where the audio may consume a large share of the
+// bandwidth without a perceptible benefit
+func (d *Detector) DetectQualityTierMismatch(videoCodec string, videoBitrate int64, audioCodec string, audioBitrate int64, audioStreamIndex int) {
+	if !IsLosslessCodec(audioCodec) || IsLosslessCodec(videoCodec) {
+		return
+	}
+	if videoBitrate <= 0 || videoBitrate >= lowBitrateVideo {
+		return
+	}
+
+	details := fmt.Sprintf("Video: %s (lossy) at %.2f Mbps, audio: %s (lossless)", videoCodec, float64(videoBitrate)/1e6, audioCodec)
+	if audioBitrate > 0 {
+		details += fmt.Sprintf(" at %.2f Mbps, %.0f%% of the video bitrate", float64(audioBitrate)/1e6, float64(audioBitrate)/float64(videoBitrate)*100)
+	}
+
+	d.addProblem(Problem{
+		Severity:    SeverityInfo,
+		Category:    CategoryAudio,
+		Code:        "MISMATCHED_QUALITY_TIERS",
+		Message:     "Lossless audio is paired with low-bitrate lossy video",
+		Details:     details,
+		Suggestion:  "For streaming, encode the audio with a lossy codec such as AAC or Opus to save bandwidth",
+		StreamIndex: audioStreamIndex,
+	})
+}
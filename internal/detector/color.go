@@ -0,0 +1,85 @@
+package detector
+
+import (
+	"fmt"
+	"strings"
+)
+
+// colorMatrixNames are readable names for ffprobe color_space values, which
+// carry the YCbCr matrix coefficients
+var colorMatrixNames = map[string]string{
+	"bt709":     "BT.709",
+	"smpte170m": "BT.601",
+	"bt470bg":   "BT.601",
+	"bt2020nc":  "BT.2020",
+	"bt2020c":   "BT.2020",
+}
+
+// expectedColorMatrix returns the matrix name expected for a video and the
+// ffprobe color_space values that satisfy it: BT.2020 for HDR, BT.709 for HD
+// and BT.601 for SD. UHD SDR is commonly mastered in either BT.709 or
+// BT.2020, so both are accepted there.
+func expectedColorMatrix(transfer string, width, height int) (string, []string) {
+	switch {
+	case IsPQTransfer(transfer) || strings.EqualFold(transfer, "arib-std-b67"):
+		return "BT.2020", []string{"bt2020nc", "bt2020c"}
+	case width >= 3840 || height >= 2160:
+		return "BT.2020", []string{"bt2020nc", "bt2020c", "bt709"}
+	case width > 1024 || height > 576:
+		return "BT.709", []string{"bt709"}
+	default:
+		return "BT.601", []string{"smpte170m", "bt470bg"}
+	}
+}
+
+// DetectColorMatrix checks a YUV video's matrix coefficients (ffprobe
+// color_space) against the one expected for its resolution class and
+// transfer. A missing matrix is noted, since players then guess from the
+// resolution; a different one is flagged as it shifts colors on playback.
+func (d *Detector) DetectColorMatrix(matrix, transfer, pixelFormat string, width, height, streamIndex int) {
+	if width <= 0 || height <= 0 {
+		return
+	}
+	// RGB has no matrix coefficients
+	for _, prefix := range []string{"rgb", "bgr", "gbr"} {
+		if strings.HasPrefix(pixelFormat, prefix) {
+			return
+		}
+	}
+
+	expected, accepted := expectedColorMatrix(transfer, width, height)
+	if matrix == "" || matrix == "unknown" {
+		d.addProblem(Problem{
+			Severity:    SeverityInfo,
+			Category:    CategoryCompatibility,
+			Code:        "COLOR_MATRIX_UNSPECIFIED",
+			Message:     fmt.Sprintf("Color matrix is not signaled for %dx%d video", width, height),
+			Details:     fmt.Sprintf("Detected: unspecified, expected: %s", expected),
+			Suggestion:  "Tag the matrix coefficients when encoding (e.g. ffmpeg -colorspace bt709) so players do not have to guess",
+			StreamIndex: streamIndex,
+		})
+		return
+	}
+	if matrix == "gbr" {
+		return
+	}
+	for _, value := range accepted {
+		if matrix == value {
+			return
+		}
+	}
+
+	detected := matrix
+	if name, ok := colorMatrixNames[matrix]; ok {
+		detected = fmt.Sprintf("%s (%s)", name, matrix)
+	}
+	d.addProblem(Problem{
+		Severity:    SeverityWarning,
+		Category:    CategoryCompatibility,
+		Code:        "COLOR_MATRIX_MISMATCH",
+		Message:     fmt.Sprintf("Color matrix %s is unusual for %dx%d video", detected, width, height),
+		Details:     fmt.Sprintf("Detected: %s, expected: %s", detected, expected),
+		Suggestion:  "Check the source's color matrix; convert with a colorspace-aware scaler (e.g. ffmpeg -vf colorspace) or correct the tag if it is wrong",
+		StreamIndex: streamIndex,
+	})
+}
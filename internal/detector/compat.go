@@ -0,0 +1,152 @@
+package detector
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CompatMatrix is a user-supplied set of codec/container compatibility
+// rules, loaded from a JSON or YAML file
+type CompatMatrix struct {
+	ReplaceBuiltin bool         `json:"replace_builtin,omitempty" yaml:"replace_builtin,omitempty"` // Drop the built-in rules instead of merging
+	Rules          []CompatRule `json:"rules" yaml:"rules"`
+}
+
+// CompatRule matches a codec/profile/level/container combination. Empty
+// fields match anything; levels use ffprobe's integer level (e.g. 41 for
+// H.264 level 4.1).
+type CompatRule struct {
+	Codec      string `json:"codec" yaml:"codec"`
+	Profile    string `json:"profile,omitempty" yaml:"profile,omitempty"`
+	MinLevel   int    `json:"min_level,omitempty" yaml:"min_level,omitempty"`
+	MaxLevel   int    `json:"max_level,omitempty" yaml:"max_level,omitempty"`
+	Container  string `json:"container,omitempty" yaml:"container,omitempty"`
+	Severity   string `json:"severity" yaml:"severity"`
+	Code       string `json:"code" yaml:"code"`
+	Message    string `json:"message" yaml:"message"`
+	Suggestion string `json:"suggestion,omitempty" yaml:"suggestion,omitempty"`
+
+	severity Severity
+}
+
+// LoadCompatMatrix reads and validates a compatibility matrix. Files ending
+// in .yaml or .yml are parsed as YAML, anything else as JSON.
+func LoadCompatMatrix(path string) (*CompatMatrix, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read compatibility matrix: %w", err)
+	}
+
+	var matrix CompatMatrix
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&matrix)
+	default:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&matrix)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse compatibility matrix %s: %w", path, err)
+	}
+
+	if err := matrix.validate(); err != nil {
+		return nil, fmt.Errorf("invalid compatibility matrix %s: %w", path, err)
+	}
+	return &matrix, nil
+}
+
+func (m *CompatMatrix) validate() error {
+	if len(m.Rules) == 0 {
+		return fmt.Errorf("no rules defined")
+	}
+
+	for i := range m.Rules {
+		rule := &m.Rules[i]
+		if rule.Codec == "" {
+			return fmt.Errorf("rule %d: codec is required", i+1)
+		}
+		if rule.Code == "" {
+			return fmt.Errorf("rule %d: code is required", i+1)
+		}
+		if rule.Message == "" {
+			return fmt.Errorf("rule %d (%s): message is required", i+1, rule.Code)
+		}
+		if rule.MaxLevel > 0 && rule.MinLevel > rule.MaxLevel {
+			return fmt.Errorf("rule %d (%s): min_level %d exceeds max_level %d", i+1, rule.Code, rule.MinLevel, rule.MaxLevel)
+		}
+		severity, err := ParseSeverity(rule.Severity)
+		if err != nil {
+			return fmt.Errorf("rule %d (%s): %w", i+1, rule.Code, err)
+		}
+		rule.severity = severity
+		rule.Code = strings.ToUpper(rule.Code)
+	}
+	return nil
+}
+
+// matches reports whether the rule applies to the given stream. container
+// is ffprobe's comma-separated format name, e.g. "mov,mp4,m4a,3gp,3g2,mj2".
+func (r *CompatRule) matches(codec, profile string, level int, container string) bool {
+	if r.Codec != "*" && !strings.EqualFold(r.Codec, codec) {
+		return false
+	}
+	if r.Profile != "" && !strings.EqualFold(r.Profile, profile) {
+		return false
+	}
+	if r.MinLevel > 0 && level < r.MinLevel {
+		return false
+	}
+	if r.MaxLevel > 0 && level > r.MaxLevel {
+		return false
+	}
+	if r.Container != "" {
+		found := false
+		for _, name := range strings.Split(container, ",") {
+			if strings.EqualFold(strings.TrimSpace(name), r.Container) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// SetCompatMatrix installs custom compatibility rules used by
+// AnalyzeCompatibility
+func (d *Detector) SetCompatMatrix(matrix *CompatMatrix) {
+	d.compatMatrix = matrix
+}
+
+// applyCompatMatrix reports a problem for every custom rule matching the stream
+func (d *Detector) applyCompatMatrix(codec, profile string, level int, container string) {
+	if d.compatMatrix == nil {
+		return
+	}
+
+	for i := range d.compatMatrix.Rules {
+		rule := &d.compatMatrix.Rules[i]
+		if !rule.matches(codec, profile, level, container) {
+			continue
+		}
+		d.addProblem(Problem{
+			Severity:   rule.severity,
+			Category:   CategoryCompatibility,
+			Code:       rule.Code,
+			Message:    rule.Message,
+			Details:    fmt.Sprintf("Codec: %s, profile: %s, level: %d, container: %s", codec, profile, level, container),
+			Suggestion: rule.Suggestion,
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package detector
+
+// DetectorConfig holds tunable detection thresholds, so stricter checks can
+// be run for broadcast content and looser ones for user-generated video.
+// Zero fields fall back to the defaults below.
+type DetectorConfig struct {
+	IrregularKeyframeThreshold float64 `json:"irregular_keyframe_threshold,omitempty"` // Fractional deviation from the average keyframe interval
+	BitrateVariationCV         float64 `json:"bitrate_variation_cv,omitempty"`         // Coefficient of variation that flags BITRATE_HIGH_VARIANCE
+	BitrateSpikeFactor         float64 `json:"bitrate_spike_factor,omitempty"`         // Multiple of the average bitrate that flags BITRATE_SPIKE
+	MaxKeyframeInterval        float64 `json:"max_keyframe_interval,omitempty"`        // Average keyframe interval in seconds that flags LARGE_KEYFRAME_INTERVAL
+	PTSGapThreshold            float64 `json:"pts_gap_threshold,omitempty"`            // Frame PTS gap in seconds that flags LARGE_PTS_GAP
+	PacketLossGap              float64 `json:"packet_loss_gap,omitempty"`              // Packet PTS jump in seconds that flags POTENTIAL_PACKET_LOSS
+	AVSyncThreshold            float64 `json:"av_sync_threshold,omitempty"`            // Audio/video drift in seconds that flags AV_SYNC_DRIFT
+	AudioTrackDelayTolerance   float64 `json:"audio_track_delay_tolerance,omitempty"`  // Audio/video start offset in seconds that flags AUDIO_TRACK_DELAY_MISMATCH
+	MaxGOPDuration             float64 `json:"max_gop_duration,omitempty"`             // GOP length in seconds that flags LONG_GOP
+	VFRThresholdPercent        float64 `json:"vfr_threshold_percent,omitempty"`        // Percent difference between r_frame_rate and avg_frame_rate that flags VARIABLE_FRAME_RATE
+}
+
+// Default thresholds used when a DetectorConfig field is zero
+const (
+	defaultIrregularKeyframeThreshold = 0.5
+	defaultBitrateVariationCV         = 0.3
+	defaultBitrateSpikeFactor         = 2.5
+	defaultMaxKeyframeInterval        = 10.0
+	defaultPTSGapThreshold            = 1.0
+	defaultPacketLossGap              = 0.5
+	defaultAVSyncThreshold            = 0.1
+	defaultAudioTrackDelayTolerance   = 0.04
+	defaultMaxGOPDuration             = 10.0
+	defaultVFRThresholdPercent        = 1.0
+)
+
+// DefaultDetectorConfig returns the thresholds used when none are given
+func DefaultDetectorConfig() DetectorConfig {
+	return DetectorConfig{}.withDefaults()
+}
+
+// withDefaults returns a copy of c with zero fields replaced by defaults
+func (c DetectorConfig) withDefaults() DetectorConfig {
+	if c.IrregularKeyframeThreshold <= 0 {
+		c.IrregularKeyframeThreshold = defaultIrregularKeyframeThreshold
+	}
+	if c.BitrateVariationCV <= 0 {
+		c.BitrateVariationCV = defaultBitrateVariationCV
+	}
+	if c.BitrateSpikeFactor <= 0 {
+		c.BitrateSpikeFactor = defaultBitrateSpikeFactor
+	}
+	if c.MaxKeyframeInterval <= 0 {
+		c.MaxKeyframeInterval = defaultMaxKeyframeInterval
+	}
+	if c.PTSGapThreshold <= 0 {
+		c.PTSGapThreshold = defaultPTSGapThreshold
+	}
+	if c.PacketLossGap <= 0 {
+		c.PacketLossGap = defaultPacketLossGap
+	}
+	if c.AVSyncThreshold <= 0 {
+		c.AVSyncThreshold = defaultAVSyncThreshold
+	}
+	if c.AudioTrackDelayTolerance <= 0 {
+		c.AudioTrackDelayTolerance = defaultAudioTrackDelayTolerance
+	}
+	if c.MaxGOPDuration <= 0 {
+		c.MaxGOPDuration = defaultMaxGOPDuration
+	}
+	if c.VFRThresholdPercent <= 0 {
+		c.VFRThresholdPercent = defaultVFRThresholdPercent
+	}
+	return c
+}
@@ -3,19 +3,24 @@ package detector
 import (
 	"fmt"
 	"math"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
 	"strings"
 )
 
 type Problem struct {
-	Severity    Severity          `json:"severity"`
-	Category    Category          `json:"category"`
-	Code        string            `json:"code"`
-	Message     string            `json:"message"`
-	Details     string            `json:"details,omitempty"`
-	Suggestion  string            `json:"suggestion,omitempty"`
-	Timestamp   float64           `json:"timestamp,omitempty"`
-	StreamIndex int               `json:"stream_index,omitempty"`
-	Metadata    map[string]string `json:"metadata,omitempty"`
+	Severity         Severity          `json:"severity"`
+	Category         Category          `json:"category"`
+	Code             string            `json:"code"`
+	Message          string            `json:"message"`
+	Details          string            `json:"details,omitempty"`
+	Suggestion       string            `json:"suggestion,omitempty"`
+	Timestamp        float64           `json:"timestamp,omitempty"`
+	StreamIndex      int               `json:"stream_index,omitempty"`
+	Metadata         map[string]string `json:"metadata,omitempty"`
+	OriginalSeverity *Severity         `json:"original_severity,omitempty"` // Set when a severity override applied
 }
 
 type Severity int
@@ -42,6 +47,42 @@ func (s Severity) String() string {
 	}
 }
 
+// Rank orders severities by how bad they are: info 0, warning 1, error 2,
+// critical 3. The constant values predate this ordering (SeverityError is
+// numerically above SeverityCritical) and are kept for compatibility, so
+// compare or sort severities by Rank, never by their numeric value.
+func (s Severity) Rank() int {
+	switch s {
+	case SeverityInfo:
+		return 0
+	case SeverityWarning:
+		return 1
+	case SeverityError:
+		return 2
+	case SeverityCritical:
+		return 3
+	default:
+		return -1
+	}
+}
+
+// ParseSeverity converts a severity name (info, warning, critical, error)
+// to a Severity, ignoring case
+func ParseSeverity(name string) (Severity, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "info":
+		return SeverityInfo, nil
+	case "warning", "warn":
+		return SeverityWarning, nil
+	case "critical":
+		return SeverityCritical, nil
+	case "error":
+		return SeverityError, nil
+	default:
+		return SeverityInfo, fmt.Errorf("unknown severity %q (expected info, warning, critical or error)", name)
+	}
+}
+
 type Category int
 
 const (
@@ -85,27 +126,232 @@ func (c Category) String() string {
 }
 
 type Detector struct {
-	problems []Problem
+	problems          []Problem
+	severityOverrides map[string]Severity
+	compatMatrix      *CompatMatrix
+	maxProblems       int // 0 means unlimited
+	suppressed        int // problems dropped after maxProblems was reached
+	config            DetectorConfig
 }
 
-func New() *Detector {
+// New creates a detector. An optional DetectorConfig tunes thresholds;
+// without one (or for zero fields) the defaults apply.
+func New(configs ...DetectorConfig) *Detector {
+	var config DetectorConfig
+	if len(configs) > 0 {
+		config = configs[0]
+	}
 	return &Detector{
 		problems: make([]Problem, 0),
+		config:   config.withDefaults(),
 	}
 }
 
 func (d *Detector) GetProblems() []Problem {
-	return d.problems
+	if d.suppressed == 0 {
+		return d.problems
+	}
+
+	problems := make([]Problem, len(d.problems), len(d.problems)+1)
+	copy(problems, d.problems)
+	return append(problems, Problem{
+		Severity:   SeverityInfo,
+		Category:   CategoryContainer,
+		Code:       "PROBLEM_LIMIT_REACHED",
+		Message:    fmt.Sprintf("Problem limit of %d reached; %d further problems were suppressed", d.maxProblems, d.suppressed),
+		Suggestion: "Raise --max-problems to see every problem",
+	})
+}
+
+// GetProblemsBySeverity returns the problems whose severity ranks at or
+// above min, e.g. SeverityWarning keeps warnings, errors and criticals
+func (d *Detector) GetProblemsBySeverity(min Severity) []Problem {
+	return FilterBySeverity(d.GetProblems(), min)
+}
+
+// FilterBySeverity returns the problems whose severity ranks at or above min
+func FilterBySeverity(problems []Problem, min Severity) []Problem {
+	filtered := make([]Problem, 0, len(problems))
+	for _, p := range problems {
+		if p.Severity.Rank() >= min.Rank() {
+			filtered = append(filtered, p)
+		}
+	}
+	return filtered
+}
+
+// SetMaxProblems caps the number of problems recorded; 0 removes the cap
+func (d *Detector) SetMaxProblems(max int) {
+	d.maxProblems = max
+}
+
+// SetSeverityOverrides remaps the severity of problems by code, e.g. to
+// downgrade HEVC_SUPPORT to info or upgrade LARGE_KEYFRAME_INTERVAL to error
+func (d *Detector) SetSeverityOverrides(overrides map[string]Severity) {
+	d.severityOverrides = overrides
 }
 
 func (d *Detector) addProblem(problem Problem) {
+	if d.maxProblems > 0 && len(d.problems) >= d.maxProblems {
+		d.suppressed++
+		return
+	}
+
+	if severity, ok := d.severityOverrides[problem.Code]; ok && severity != problem.Severity {
+		original := problem.Severity
+		problem.OriginalSeverity = &original
+		problem.Severity = severity
+	}
 	d.problems = append(d.problems, problem)
 }
 
+// VideoParams carries the video stream properties checked by
+// DetectVideoProblems and DetectVariableFrameRate
+type VideoParams struct {
+	Index        int
+	Codec        string
+	Profile      string
+	Width        int
+	Height       int
+	FrameRate    string  // ffprobe r_frame_rate, e.g. "24000/1001"
+	FPS          float64 // FrameRate as a number; 0 when undefined
+	AvgFrameRate string  // ffprobe avg_frame_rate
+	AvgFPS       float64 // AvgFrameRate as a number; 0 when undefined
+	PixelFormat  string
+	Bitrate      int64 // Video bitrate in bits/s; 0 when unknown
+}
+
+// standardFrameRates are the frame rates players and broadcast chains
+// expect, as exact fractions
+var standardFrameRates = [][2]int64{
+	{24000, 1001}, {24, 1}, {25, 1}, {30000, 1001}, {30, 1}, {48, 1},
+	{50, 1}, {60000, 1001}, {60, 1}, {100, 1}, {120000, 1001}, {120, 1},
+}
+
+// poorlySupportedPixelFormats are pixel format prefixes that hardware
+// decoders on consumer devices commonly reject
+var poorlySupportedPixelFormats = []string{"yuv444", "yuvj444", "yuv422", "yuvj422", "gbr", "rgb", "bgr"}
+
+// bitsPerPixelEfficiency scales the bits-per-pixel thresholds by how much
+// data each delivery codec needs for comparable quality, relative to H.264.
+// Intra-only and mezzanine codecs are absent and never checked.
+var bitsPerPixelEfficiency = map[string]float64{
+	"mpeg2video": 2.0,
+	"mpeg4":      1.5,
+	"h264":       1.0,
+	"hevc":       0.6,
+	"vp8":        1.0,
+	"vp9":        0.6,
+	"av1":        0.5,
+}
+
+// Bits per pixel per frame, for H.264, below which video is likely to look
+// blocky and above which bitrate is being wasted
+const (
+	lowBitsPerPixel  = 0.02
+	highBitsPerPixel = 0.5
+)
+
 // DetectVideoProblems checks for common video stream issues
-func (d *Detector) DetectVideoProblems(videoInfo interface{}) {
-	// This will be populated with actual video info from analyzer
-	// Placeholder for video problem detection logic
+func (d *Detector) DetectVideoProblems(video VideoParams) {
+	if video.Width%2 != 0 || video.Height%2 != 0 {
+		d.addProblem(Problem{
+			Severity:    SeverityWarning,
+			Category:    CategoryResolution,
+			Code:        "ODD_DIMENSIONS",
+			Message:     fmt.Sprintf("Resolution %dx%d has an odd dimension", video.Width, video.Height),
+			Suggestion:  "Scale or crop to even dimensions; many encoders and chroma-subsampled formats require them",
+			StreamIndex: video.Index,
+		})
+	}
+
+	// Equal fractions divide to the same float64, so only a different
+	// fraction of a nearby rate gets past the equality check
+	if fps := video.FPS; fps > 0 {
+		for _, std := range standardFrameRates {
+			stdFPS := float64(std[0]) / float64(std[1])
+			if math.Abs(fps-stdFPS) > 0.01 || fps == stdFPS {
+				continue
+			}
+			d.addProblem(Problem{
+				Severity:    SeverityWarning,
+				Category:    CategoryFrameRate,
+				Code:        "NONSTANDARD_FRAME_RATE",
+				Message:     fmt.Sprintf("Frame rate %s (%.4f fps) approximates but is not exactly %d/%d", video.FrameRate, fps, std[0], std[1]),
+				Details:     fmt.Sprintf("Stored fraction: %s, standard: %d/%d (%.4f fps)", video.FrameRate, std[0], std[1], stdFPS),
+				Suggestion:  fmt.Sprintf("Re-mux or re-encode with an exact %d/%d frame rate to avoid drift and player judder", std[0], std[1]),
+				StreamIndex: video.Index,
+			})
+			break
+		}
+	}
+
+	if strings.EqualFold(video.Codec, "h264") && strings.Contains(strings.ToLower(video.Profile), "baseline") &&
+		video.Width*video.Height > 3840*2160 {
+		d.addProblem(Problem{
+			Severity:    SeverityWarning,
+			Category:    CategoryCodec,
+			Code:        "H264_BASELINE_ABOVE_4K",
+			Message:     fmt.Sprintf("H.264 %s profile used for %dx%d, above 4K", video.Profile, video.Width, video.Height),
+			Suggestion:  "Use High profile (or HEVC/AV1) for resolutions above 4K; Baseline decoders rarely support them",
+			StreamIndex: video.Index,
+		})
+	}
+
+	pixFmt := strings.ToLower(video.PixelFormat)
+	for _, prefix := range poorlySupportedPixelFormats {
+		if strings.HasPrefix(pixFmt, prefix) {
+			d.addProblem(Problem{
+				Severity:    SeverityWarning,
+				Category:    CategoryCodec,
+				Code:        "POOR_PIXEL_FORMAT_SUPPORT",
+				Message:     fmt.Sprintf("Pixel format %s has poor device support", video.PixelFormat),
+				Suggestion:  "Convert to yuv420p (or yuv420p10le for HDR) for broad hardware decoder compatibility",
+				StreamIndex: video.Index,
+			})
+			break
+		}
+	}
+
+	d.detectBitsPerPixel(video)
+}
+
+// detectBitsPerPixel flags video whose bitrate is implausibly low or high
+// for its resolution and frame rate. It is skipped when the bitrate or frame
+// rate is unknown.
+func (d *Detector) detectBitsPerPixel(video VideoParams) {
+	efficiency, ok := bitsPerPixelEfficiency[strings.ToLower(video.Codec)]
+	fps := video.FPS
+	if !ok || video.Bitrate <= 0 || fps <= 0 || video.Width <= 0 || video.Height <= 0 {
+		return
+	}
+
+	bpp := float64(video.Bitrate) / (float64(video.Width*video.Height) * fps)
+	details := fmt.Sprintf("%.4f bits/pixel/frame at %dx%d, %.3f fps, %.0f kbps (%s)",
+		bpp, video.Width, video.Height, fps, float64(video.Bitrate)/1000, video.Codec)
+
+	switch {
+	case bpp < lowBitsPerPixel*efficiency:
+		d.addProblem(Problem{
+			Severity:    SeverityWarning,
+			Category:    CategoryBitrate,
+			Code:        "LOW_BITS_PER_PIXEL",
+			Message:     fmt.Sprintf("Video bitrate is very low for %dx%d at %.2f fps; expect blocking", video.Width, video.Height, fps),
+			Details:     details,
+			Suggestion:  fmt.Sprintf("Raise the bitrate to at least %.0f kbps or lower the resolution/frame rate", lowBitsPerPixel*efficiency*float64(video.Width*video.Height)*fps/1000),
+			StreamIndex: video.Index,
+		})
+	case bpp > highBitsPerPixel*efficiency:
+		d.addProblem(Problem{
+			Severity:    SeverityInfo,
+			Category:    CategoryBitrate,
+			Code:        "HIGH_BITS_PER_PIXEL",
+			Message:     fmt.Sprintf("Video bitrate is unusually high for %dx%d at %.2f fps", video.Width, video.Height, fps),
+			Details:     details,
+			Suggestion:  "For delivery, a lower bitrate or CRF-based encode usually gives the same visual quality in far fewer bytes",
+			StreamIndex: video.Index,
+		})
+	}
 }
 
 // DetectAudioProblems checks for common audio stream issues
@@ -113,9 +359,104 @@ func (d *Detector) DetectAudioProblems(audioInfo interface{}) {
 	// Placeholder for audio problem detection logic
 }
 
-// DetectContainerProblems checks for container format issues
-func (d *Detector) DetectContainerProblems(formatInfo interface{}) {
-	// Placeholder for container problem detection logic
+// ContainerParams carries the container fields DetectContainerProblems
+// inspects, mirroring the analyzer's FormatInfo
+type ContainerParams struct {
+	Input          string // File path or URL, used for the extension check
+	FormatName     string // ffprobe format_name, e.g. "mov,mp4,m4a,3gp,3g2,mj2"
+	Duration       float64
+	DurationSource string
+	Size           int64
+	ProbeScore     int
+}
+
+// lowProbeScore is the ffprobe probe score below which the demuxer choice is
+// a guess rather than a confident match (100 means certain)
+const lowProbeScore = 50
+
+// largeContainerSize is the size above which a missing duration means real
+// content players cannot seek through, rather than an empty stub file
+const largeContainerSize = 10 * 1024 * 1024
+
+// extensionFormats maps file extensions to the ffprobe format names that
+// legitimately carry them
+var extensionFormats = map[string][]string{
+	".mp4":  {"mp4", "mov"},
+	".m4v":  {"mp4", "mov"},
+	".m4a":  {"mp4", "mov", "m4a"},
+	".mov":  {"mov", "mp4"},
+	".3gp":  {"3gp", "mov"},
+	".mkv":  {"matroska"},
+	".webm": {"webm", "matroska"},
+	".avi":  {"avi"},
+	".flv":  {"flv"},
+	".ts":   {"mpegts"},
+	".m2ts": {"mpegts"},
+	".mts":  {"mpegts"},
+	".mpg":  {"mpeg", "mpegvideo"},
+	".mpeg": {"mpeg", "mpegvideo"},
+	".mxf":  {"mxf"},
+	".wmv":  {"asf"},
+	".asf":  {"asf"},
+	".ogg":  {"ogg"},
+	".ogv":  {"ogg"},
+	".mp3":  {"mp3"},
+	".wav":  {"wav"},
+	".flac": {"flac"},
+	".aac":  {"aac"},
+	".m3u8": {"hls"},
+	".mpd":  {"dash"},
+}
+
+// inputExtension returns the lower-cased extension of a path or URL path
+func inputExtension(input string) string {
+	if u, err := url.Parse(input); err == nil && u.Scheme != "" && len(u.Scheme) > 1 {
+		input = u.Path
+	}
+	return strings.ToLower(path.Ext(input))
+}
+
+// DetectContainerProblems checks for container format issues: a low probe
+// score, a file extension that disagrees with the detected format, and a
+// missing duration (see DetectContainerDuration)
+func (d *Detector) DetectContainerProblems(p ContainerParams) {
+	if p.ProbeScore > 0 && p.ProbeScore < lowProbeScore {
+		d.addProblem(Problem{
+			Severity:   SeverityWarning,
+			Category:   CategoryContainer,
+			Code:       "LOW_PROBE_SCORE",
+			Message:    fmt.Sprintf("Format %s was detected with low confidence", p.FormatName),
+			Details:    fmt.Sprintf("Probe score: %d/100 (below %d)", p.ProbeScore, lowProbeScore),
+			Suggestion: "The file may be damaged or mislabelled; use --input-format to force the demuxer",
+		})
+	}
+
+	ext := inputExtension(p.Input)
+	if expected, ok := extensionFormats[ext]; ok && !formatMatchesAny(p.FormatName, expected) {
+		d.addProblem(Problem{
+			Severity:   SeverityWarning,
+			Category:   CategoryContainer,
+			Code:       "EXTENSION_FORMAT_MISMATCH",
+			Message:    fmt.Sprintf("File extension %s does not match detected format %s", ext, p.FormatName),
+			Details:    fmt.Sprintf("Expected one of: %s", strings.Join(expected, ", ")),
+			Suggestion: "Rename the file to match its container or remux it; some players pick a demuxer by extension",
+		})
+	}
+
+	d.DetectContainerDuration(p.FormatName, p.Duration, p.DurationSource, p.Size)
+}
+
+// formatMatchesAny reports whether any name in a comma-separated ffprobe
+// format_name is one of names
+func formatMatchesAny(formatName string, names []string) bool {
+	for _, part := range strings.Split(strings.ToLower(formatName), ",") {
+		for _, name := range names {
+			if strings.TrimSpace(part) == name {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // DetectBitrateVariations analyzes bitrate consistency over time
@@ -124,28 +465,26 @@ func (d *Detector) DetectBitrateVariations(packets []PacketInfo) {
 		return
 	}
 
-	// Calculate average bitrate
+	// Group packets into 1 second windows from the first packet. Empty
+	// windows are gaps, which DetectPacketLoss reports, so they are left out
+	// of the statistics rather than dragging the average down.
+	timeWindow := 1.0
+	start, ok := bitrateStart(packets)
+	if !ok {
+		return
+	}
 	var totalBitrate float64
 	var avgBitrate float64
 	bitratePoints := make([]float64, 0)
-
-	// Group packets by time window (1 second)
-	timeWindow := 1.0
-	currentWindow := 0.0
-	windowBytes := 0
-
-	for _, packet := range packets {
-		if packet.PTS > currentWindow+timeWindow {
-			if windowBytes > 0 {
-				bitrate := float64(windowBytes) * 8 / timeWindow
-				bitratePoints = append(bitratePoints, bitrate)
-				totalBitrate += bitrate
-			}
-			currentWindow = packet.PTS
-			windowBytes = packet.Size
-		} else {
-			windowBytes += packet.Size
+	bitrateTimes := make([]float64, 0)
+	for n, windowBytes := range bitrateWindows(packets, start, timeWindow) {
+		if windowBytes == 0 {
+			continue
 		}
+		bitrate := float64(windowBytes) * 8 / timeWindow
+		bitratePoints = append(bitratePoints, bitrate)
+		bitrateTimes = append(bitrateTimes, start+float64(n)*timeWindow)
+		totalBitrate += bitrate
 	}
 
 	if len(bitratePoints) == 0 {
@@ -163,7 +502,7 @@ func (d *Detector) DetectBitrateVariations(packets []PacketInfo) {
 
 	// Check for high variance
 	coefficientOfVariation := stdDev / avgBitrate
-	if coefficientOfVariation > 0.3 {
+	if coefficientOfVariation > d.config.BitrateVariationCV {
 		d.addProblem(Problem{
 			Severity:   SeverityWarning,
 			Category:   CategoryBitrate,
@@ -176,15 +515,15 @@ func (d *Detector) DetectBitrateVariations(packets []PacketInfo) {
 
 	// Check for bitrate spikes
 	for i, bitrate := range bitratePoints {
-		if bitrate > avgBitrate*2.5 {
+		if bitrate > avgBitrate*d.config.BitrateSpikeFactor {
 			d.addProblem(Problem{
 				Severity:   SeverityWarning,
 				Category:   CategoryBitrate,
 				Code:       "BITRATE_SPIKE",
-				Message:    fmt.Sprintf("Bitrate spike detected at ~%.2fs", float64(i)*timeWindow),
+				Message:    fmt.Sprintf("Bitrate spike detected at ~%.2fs", bitrateTimes[i]),
 				Details:    fmt.Sprintf("Spike: %.2f Mbps (avg: %.2f Mbps)", bitrate/1000000, avgBitrate/1000000),
 				Suggestion: "Review encoding settings or source content at this timestamp",
-				Timestamp:  float64(i) * timeWindow,
+				Timestamp:  bitrateTimes[i],
 			})
 		}
 	}
@@ -196,8 +535,14 @@ func (d *Detector) DetectKeyframeIssues(frames []FrameInfo) {
 		return
 	}
 
+	// Only the main video stream's keyframes form an interval series (audio
+	// frames are all keyframes), taken in the same PTS order as BuildGOPs
+	video := mainVideoFrames(frames)
+	if len(video) == 0 {
+		return
+	}
 	keyframes := make([]FrameInfo, 0)
-	for _, frame := range frames {
+	for _, frame := range video {
 		if frame.KeyFrame {
 			keyframes = append(keyframes, frame)
 		}
@@ -228,23 +573,38 @@ func (d *Detector) DetectKeyframeIssues(frames []FrameInfo) {
 	}
 	avgInterval := totalInterval / float64(len(intervals))
 
-	// Check for irregular keyframe intervals
-	//for i, interval := range intervals {
-	//	if math.Abs(interval-avgInterval)/avgInterval > 0.5 {
-	//		d.addProblem(Problem{
-	//			Severity:  SeverityWarning,
-	//			Category:  CategoryKeyframe,
-	//			Code:      "IRREGULAR_KEYFRAME_INTERVAL",
-	//			Message:   fmt.Sprintf("Irregular keyframe interval at keyframe %d", i+1),
-	//			Details:   fmt.Sprintf("Interval: %.2fs (expected: %.2fs)", interval, avgInterval),
-	//			Timestamp: keyframes[i].PTS,
-	//			Suggestion: "Consider using fixed GOP size for consistent keyframe intervals",
-	//		})
-	//	}
-	//}
+	// Check for irregular keyframe intervals. The first and last intervals
+	// border the file boundaries, where encoders and segmenters often cut a
+	// partial GOP, so they are neither flagged nor counted in the expected
+	// interval when there are interior intervals to compare against.
+	first, last := 0, len(intervals)
+	if len(intervals) > 2 {
+		first, last = 1, len(intervals)-1
+	}
+	var interiorTotal float64
+	for _, interval := range intervals[first:last] {
+		interiorTotal += interval
+	}
+	expected := interiorTotal / float64(last-first)
+	if expected > 0 {
+		for i := first; i < last; i++ {
+			interval := intervals[i]
+			if math.Abs(interval-expected)/expected > d.config.IrregularKeyframeThreshold {
+				d.addProblem(Problem{
+					Severity:   SeverityWarning,
+					Category:   CategoryKeyframe,
+					Code:       "IRREGULAR_KEYFRAME_INTERVAL",
+					Message:    fmt.Sprintf("Irregular keyframe interval at keyframe %d", i+2),
+					Details:    fmt.Sprintf("Interval: %.2fs (expected: %.2fs)", interval, expected),
+					Timestamp:  keyframes[i+1].PTS,
+					Suggestion: "Consider using fixed GOP size for consistent keyframe intervals",
+				})
+			}
+		}
+	}
 
 	// Check if keyframe interval is too large for streaming
-	if avgInterval > 10.0 {
+	if avgInterval > d.config.MaxKeyframeInterval {
 		d.addProblem(Problem{
 			Severity:   SeverityWarning,
 			Category:   CategoryKeyframe,
@@ -277,7 +637,7 @@ func (d *Detector) DetectTimestampIssues(frames []FrameInfo) {
 
 		// Check for large PTS gaps
 		ptsDiff := frames[i].PTS - frames[i-1].PTS
-		if ptsDiff > 1.0 { // Gap larger than 1 second
+		if ptsDiff > d.config.PTSGapThreshold {
 			d.addProblem(Problem{
 				Severity:   SeverityWarning,
 				Category:   CategoryTimestamp,
@@ -319,6 +679,341 @@ func (d *Detector) DetectTimestampIssues(frames []FrameInfo) {
 	}
 }
 
+// DetectTimestampPrecision checks whether frame PTS values are written with
+// a consistent number of decimal places. A stream where some timestamps carry
+// microsecond precision and others are rounded to milliseconds usually comes
+// from a muxer that re-quantized part of the timeline, which shows up as jitter.
+func (d *Detector) DetectTimestampPrecision(frames []FrameInfo) {
+	byStream := make(map[int][]FrameInfo)
+	order := make([]int, 0)
+	for _, frame := range frames {
+		if _, ok := byStream[frame.StreamIndex]; !ok {
+			order = append(order, frame.StreamIndex)
+		}
+		byStream[frame.StreamIndex] = append(byStream[frame.StreamIndex], frame)
+	}
+
+	for _, streamIndex := range order {
+		streamFrames := byStream[streamIndex]
+		if len(streamFrames) < 10 {
+			continue
+		}
+
+		digits := make([]int, len(streamFrames))
+		var total float64
+		for i, frame := range streamFrames {
+			digits[i] = decimalPlaces(frame.PTS)
+			total += float64(digits[i])
+		}
+		mean := total / float64(len(digits))
+
+		var variance float64
+		for _, n := range digits {
+			variance += math.Pow(float64(n)-mean, 2)
+		}
+		stdDev := math.Sqrt(variance / float64(len(digits)))
+
+		// Split into coarse (<= millisecond) and fine (sub-millisecond) timestamps.
+		// Occasional coarse values are expected when a fine timestamp happens to
+		// land on a round number, so both groups must be well represented.
+		var coarse, fine []float64
+		for i, frame := range streamFrames {
+			if digits[i] <= 3 {
+				coarse = append(coarse, frame.PTS)
+			} else {
+				fine = append(fine, frame.PTS)
+			}
+		}
+		minority := math.Min(float64(len(coarse)), float64(len(fine))) / float64(len(streamFrames))
+		if stdDev < 1.0 || minority < 0.1 {
+			continue
+		}
+
+		d.addProblem(Problem{
+			Severity:    SeverityInfo,
+			Category:    CategoryTimestamp,
+			Code:        "TIMESTAMP_PRECISION_JITTER",
+			Message:     fmt.Sprintf("Inconsistent PTS precision in stream %d", streamIndex),
+			Details:     fmt.Sprintf("%d of %d timestamps rounded to milliseconds (precision stddev: %.2f digits), e.g. %s vs %s", len(coarse), len(streamFrames), stdDev, formatTimestamps(coarse, 3), formatTimestamps(fine, 3)),
+			Suggestion:  "Remux with a consistent time base to avoid playback stutter",
+			Timestamp:   coarse[0],
+			StreamIndex: streamIndex,
+		})
+	}
+}
+
+// DetectUniformFrameSizes flags video whose frames of the same picture type
+// are nearly identical in size. Natural content varies from frame to frame;
+// near-constant sizes point to test patterns, placeholders or corrupt
+// reconstructions. Only the main video stream is checked.
+func (d *Detector) DetectUniformFrameSizes(frames []FrameInfo) {
+	const (
+		minFrames = 30
+		maxCV     = 0.02
+	)
+
+	streamIndex := mainVideoStream(frames)
+	if streamIndex < 0 {
+		return
+	}
+
+	sizesByType := make(map[string][]float64)
+	types := make([]string, 0)
+	for _, frame := range frames {
+		if !strings.EqualFold(frame.MediaType, "video") || frame.AttachedPic || frame.StreamIndex != streamIndex || frame.Size <= 0 {
+			continue
+		}
+		pictType := frame.PictType
+		if pictType == "" || pictType == "?" {
+			pictType = "unknown"
+		}
+		if _, ok := sizesByType[pictType]; !ok {
+			types = append(types, pictType)
+		}
+		sizesByType[pictType] = append(sizesByType[pictType], float64(frame.Size))
+	}
+
+	for _, pictType := range types {
+		sizes := sizesByType[pictType]
+		if len(sizes) < minFrames {
+			continue
+		}
+
+		var sum float64
+		for _, size := range sizes {
+			sum += size
+		}
+		mean := sum / float64(len(sizes))
+
+		var variance float64
+		for _, size := range sizes {
+			variance += math.Pow(size-mean, 2)
+		}
+		cv := math.Sqrt(variance/float64(len(sizes))) / mean
+		if cv >= maxCV {
+			continue
+		}
+
+		d.addProblem(Problem{
+			Severity:    SeverityInfo,
+			Category:    CategoryCodec,
+			Code:        "UNIFORM_FRAME_SIZES",
+			Message:     fmt.Sprintf("%s-frame sizes are nearly constant (CV: %.4f)", pictType, cv),
+			Details:     fmt.Sprintf("%d frames, average size: %.0f bytes", len(sizes), mean),
+			Suggestion:  "Verify the content is not a test pattern, placeholder or corrupt reconstruction",
+			StreamIndex: streamIndex,
+		})
+	}
+}
+
+// decimalPlaces returns the number of significant decimal places in v
+func decimalPlaces(v float64) int {
+	s := strconv.FormatFloat(v, 'f', -1, 64)
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		return len(s) - i - 1
+	}
+	return 0
+}
+
+// formatTimestamps renders up to limit timestamps for use in problem details
+func formatTimestamps(values []float64, limit int) string {
+	parts := make([]string, 0, limit)
+	for i, v := range values {
+		if i >= limit {
+			break
+		}
+		parts = append(parts, strconv.FormatFloat(v, 'f', -1, 64))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// DetectProbeDurationMismatch compares, per stream, how far the packet probe
+// and the frame probe got (the largest PTS plus that packet's or frame's
+// duration). Both probes read the same file, so a significant difference means
+// one of them stopped early, usually on a decode error or an internal limit.
+func (d *Detector) DetectProbeDurationMismatch(packets []PacketInfo, frames []FrameInfo) {
+	packetEnd := make(map[int]float64)
+	for _, packet := range packets {
+		if end := packet.PTS + packet.Duration; end > packetEnd[packet.StreamIndex] {
+			packetEnd[packet.StreamIndex] = end
+		}
+	}
+
+	frameEnd := make(map[int]float64)
+	order := make([]int, 0)
+	for _, frame := range frames {
+		if _, ok := frameEnd[frame.StreamIndex]; !ok {
+			order = append(order, frame.StreamIndex)
+		}
+		if end := frame.PTS + frame.Duration; end > frameEnd[frame.StreamIndex] {
+			frameEnd[frame.StreamIndex] = end
+		}
+	}
+
+	for _, streamIndex := range order {
+		fromPackets, ok := packetEnd[streamIndex]
+		if !ok {
+			continue
+		}
+		fromFrames := frameEnd[streamIndex]
+
+		// Allow a second of slack (or 2% on long files) for trailing frames the
+		// decoder drops or delays
+		diff := math.Abs(fromPackets - fromFrames)
+		if diff <= 1.0 || diff <= 0.02*math.Max(fromPackets, fromFrames) {
+			continue
+		}
+
+		d.addProblem(Problem{
+			Severity:    SeverityInfo,
+			Category:    CategoryTimestamp,
+			Code:        "PACKET_FRAME_DURATION_MISMATCH",
+			Message:     fmt.Sprintf("Packet and frame probes disagree on the length of stream %d", streamIndex),
+			Details:     fmt.Sprintf("Packets end at %.3fs, frames end at %.3fs (difference %.3fs)", fromPackets, fromFrames, diff),
+			Suggestion:  "One probe stopped early; check the file for decode errors or raise the probe timeout",
+			StreamIndex: streamIndex,
+		})
+	}
+}
+
+// DetectPacketFrameDrift cross-checks packet and frame timestamps of the same
+// stream. Every decoded frame should carry the PTS of the packet it came from,
+// so a frame whose PTS is far from every packet PTS of its stream points to a
+// muxing bug (e.g. mismatched time bases). Frames are matched to the nearest
+// packet rather than by position because decoders may drop leading frames
+// and reorder B-frames.
+func (d *Detector) DetectPacketFrameDrift(packets []PacketInfo, frames []FrameInfo) {
+	// Timestamps are printed with microsecond precision; allow some slack
+	// for rounding in either probe
+	const tolerance = 0.002
+
+	packetPTS := make(map[int][]float64)
+	for _, packet := range packets {
+		packetPTS[packet.StreamIndex] = append(packetPTS[packet.StreamIndex], packet.PTS)
+	}
+	for _, pts := range packetPTS {
+		sort.Float64s(pts)
+	}
+
+	type drift struct {
+		max       float64
+		timestamp float64
+		count     int
+		checked   int
+	}
+	drifts := make(map[int]*drift)
+	order := make([]int, 0)
+
+	for _, frame := range frames {
+		pts, ok := packetPTS[frame.StreamIndex]
+		if !ok || len(pts) == 0 {
+			continue
+		}
+		// Only frames inside the probed packet range can be matched
+		if frame.PTS < pts[0]-tolerance || frame.PTS > pts[len(pts)-1]+tolerance {
+			continue
+		}
+
+		i := sort.SearchFloat64s(pts, frame.PTS)
+		nearest := math.Inf(1)
+		if i < len(pts) {
+			nearest = math.Abs(pts[i] - frame.PTS)
+		}
+		if i > 0 {
+			nearest = math.Min(nearest, math.Abs(frame.PTS-pts[i-1]))
+		}
+
+		s, ok := drifts[frame.StreamIndex]
+		if !ok {
+			s = &drift{}
+			drifts[frame.StreamIndex] = s
+			order = append(order, frame.StreamIndex)
+		}
+		s.checked++
+		if nearest > tolerance {
+			s.count++
+			if nearest > s.max {
+				s.max = nearest
+				s.timestamp = frame.PTS
+			}
+		}
+	}
+
+	for _, streamIndex := range order {
+		s := drifts[streamIndex]
+		if s.count == 0 {
+			continue
+		}
+		d.addProblem(Problem{
+			Severity:    SeverityWarning,
+			Category:    CategoryTimestamp,
+			Code:        "PACKET_FRAME_TIMESTAMP_DRIFT",
+			Message:     fmt.Sprintf("Frame timestamps drift from packet timestamps in stream %d", streamIndex),
+			Details:     fmt.Sprintf("%d of %d frames have no matching packet PTS, max drift: %.3fms", s.count, s.checked, s.max*1000),
+			Suggestion:  "Remux the file; the muxer likely wrote inconsistent time bases",
+			Timestamp:   s.timestamp,
+			StreamIndex: streamIndex,
+		})
+	}
+}
+
+// ptsWrapPeriod is the span of a 33-bit MPEG timestamp at 90kHz in seconds
+const ptsWrapPeriod = float64(int64(1)<<33) / 90000
+
+// DetectNegativeStartPTS flags streams whose earliest presentation timestamp
+// is negative, which is usually caused by edit lists or B-frame reordering
+// delay and makes some players show negative times or drop the first frames
+func (d *Detector) DetectNegativeStartPTS(packets []PacketInfo) {
+	// Because of B-frame reordering the earliest PTS is not necessarily on
+	// the first packet, so look at the leading packets of each stream
+	const leadingPackets = 16
+
+	earliest := make(map[int]float64)
+	seen := make(map[int]int)
+	maxPTS := make(map[int]float64)
+	order := make([]int, 0)
+
+	for _, packet := range packets {
+		count, ok := seen[packet.StreamIndex]
+		if !ok {
+			order = append(order, packet.StreamIndex)
+			earliest[packet.StreamIndex] = packet.PTS
+			maxPTS[packet.StreamIndex] = packet.PTS
+		}
+		if count < leadingPackets && packet.PTS < earliest[packet.StreamIndex] {
+			earliest[packet.StreamIndex] = packet.PTS
+		}
+		if packet.PTS > maxPTS[packet.StreamIndex] {
+			maxPTS[packet.StreamIndex] = packet.PTS
+		}
+		seen[packet.StreamIndex] = count + 1
+	}
+
+	for _, streamIndex := range order {
+		firstPTS := earliest[streamIndex]
+		if firstPTS >= 0 {
+			continue
+		}
+
+		// A timestamp that wrapped around the 33-bit boundary reappears near
+		// the top of the range; that is a discontinuity, not a start offset
+		if maxPTS[streamIndex]-firstPTS >= ptsWrapPeriod-60 {
+			continue
+		}
+
+		d.addProblem(Problem{
+			Severity:    SeverityWarning,
+			Category:    CategoryTimestamp,
+			Code:        "NEGATIVE_FIRST_PTS",
+			Message:     fmt.Sprintf("Stream %d starts with a negative PTS", streamIndex),
+			Details:     fmt.Sprintf("First PTS: %.6fs", firstPTS),
+			Suggestion:  "Remux with an edit list or shift timestamps (e.g. ffmpeg -avoid_negative_ts make_zero)",
+			Timestamp:   firstPTS,
+			StreamIndex: streamIndex,
+		})
+	}
+}
+
 // DetectPacketLoss analyzes for potential packet loss indicators
 func (d *Detector) DetectPacketLoss(packets []PacketInfo) {
 	if len(packets) < 2 {
@@ -329,8 +1024,8 @@ func (d *Detector) DetectPacketLoss(packets []PacketInfo) {
 	for i := 1; i < len(packets); i++ {
 		ptsDiff := packets[i].PTS - packets[i-1].PTS
 
-		// If PTS jump is larger than 0.5 seconds, might indicate loss
-		if ptsDiff > 0.5 {
+		// A large PTS jump might indicate loss
+		if ptsDiff > d.config.PacketLossGap {
 			d.addProblem(Problem{
 				Severity:   SeverityWarning,
 				Category:   CategoryPacketLoss,
@@ -344,8 +1039,136 @@ func (d *Detector) DetectPacketLoss(packets []PacketInfo) {
 	}
 }
 
+// DetectEncoderDelay checks that AAC audio signals its encoder delay
+// (priming samples). Without an iTunSMPB tag, an edit list offset or a
+// container-level initial padding, gapless players cannot trim the priming
+// samples and play a short gap or click at the start.
+func (d *Detector) DetectEncoderDelay(codec string, startTime float64, initialPadding int, streamTags, formatTags map[string]string, streamIndex int) {
+	if strings.ToLower(codec) != "aac" {
+		return
+	}
+
+	found := make([]string, 0)
+	for _, tags := range []map[string]string{streamTags, formatTags} {
+		for key, value := range tags {
+			if strings.EqualFold(key, "iTunSMPB") && strings.TrimSpace(value) != "" {
+				found = append(found, "iTunSMPB tag")
+			}
+		}
+	}
+	if startTime < 0 {
+		found = append(found, fmt.Sprintf("edit list offset (start %.6fs)", startTime))
+	}
+	if initialPadding > 0 {
+		found = append(found, fmt.Sprintf("initial padding (%d samples)", initialPadding))
+	}
+
+	if len(found) > 0 {
+		return
+	}
+
+	d.addProblem(Problem{
+		Severity:    SeverityInfo,
+		Category:    CategoryAudio,
+		Code:        "MISSING_ENCODER_DELAY_INFO",
+		Message:     "AAC audio does not signal encoder delay",
+		Details:     "No iTunSMPB tag, edit list offset, or initial padding found",
+		Suggestion:  "Remux with an edit list or iTunSMPB tag so gapless players can trim priming samples",
+		StreamIndex: streamIndex,
+	})
+}
+
+// liveCaptureFormats are demuxers whose output commonly lacks a container
+// duration because the file was captured from a live source
+var liveCaptureFormats = map[string]bool{
+	"flv":      true,
+	"live_flv": true,
+	"mpegts":   true,
+	"hls":      true,
+	"rtsp":     true,
+	"rtp":      true,
+	"sdp":      true,
+}
+
+// IsLiveCaptureFormat reports whether formatName (a comma-separated ffprobe
+// format_name) belongs to a live capture format
+func IsLiveCaptureFormat(formatName string) bool {
+	for _, name := range strings.Split(strings.ToLower(formatName), ",") {
+		if liveCaptureFormats[strings.TrimSpace(name)] {
+			return true
+		}
+	}
+	return false
+}
+
+// DetectContainerDuration checks for a missing container duration. Live
+// capture formats legitimately lack one and are only noted, reporting the
+// duration source used instead; for other formats it indicates a broken file,
+// and a large one is likely a streaming-style container that cannot be seeked.
+func (d *Detector) DetectContainerDuration(formatName string, duration float64, durationSource string, size int64) {
+	if durationSource == "container" {
+		return
+	}
+
+	if IsLiveCaptureFormat(formatName) {
+		details := "No stream duration available either"
+		if duration > 0 {
+			details = fmt.Sprintf("Using %s duration: %.3fs", durationSource, duration)
+		}
+		d.addProblem(Problem{
+			Severity: SeverityInfo,
+			Category: CategoryContainer,
+			Code:     "LIVE_CAPTURE_NO_DURATION",
+			Message:  fmt.Sprintf("Live capture format %s has no container duration", formatName),
+			Details:  details,
+		})
+		return
+	}
+
+	problem := Problem{
+		Severity:   SeverityError,
+		Category:   CategoryContainer,
+		Code:       "ZERO_CONTAINER_DURATION",
+		Message:    fmt.Sprintf("Container %s reports no duration", formatName),
+		Details:    "Duration source: none",
+		Suggestion: "The file may be truncated or was not finalized; remux it to rebuild the index",
+	}
+	if size >= largeContainerSize {
+		problem.Message = fmt.Sprintf("Container %s reports no duration for %.1f MB of data", formatName, float64(size)/(1024*1024))
+		problem.Details = fmt.Sprintf("Duration source: none; size: %d bytes", size)
+		problem.Suggestion = "The container is broken or was written as a stream and players may not be able to seek; remux it to rebuild the index"
+	}
+	d.addProblem(problem)
+}
+
+// DetectTimecode notes the presence of a starting timecode. Drop-frame
+// timecodes use ';' (or '.') before the frame field, non-drop use ':'.
+func (d *Detector) DetectTimecode(timecode string) {
+	if timecode == "" {
+		return
+	}
+
+	notation := "non-drop-frame"
+	if strings.ContainsAny(timecode, ";.") {
+		notation = "drop-frame"
+	}
+
+	d.addProblem(Problem{
+		Severity: SeverityInfo,
+		Category: CategoryContainer,
+		Code:     "HAS_TIMECODE",
+		Message:  fmt.Sprintf("Starting timecode: %s", timecode),
+		Details:  fmt.Sprintf("Timecode notation: %s", notation),
+	})
+}
+
 // AnalyzeCompatibility checks for compatibility issues
 func (d *Detector) AnalyzeCompatibility(codec string, profile string, level int, container string) {
+	d.applyCompatMatrix(codec, profile, level, container)
+	if d.compatMatrix != nil && d.compatMatrix.ReplaceBuiltin {
+		return
+	}
+
 	// Check H.264 compatibility
 	if strings.ToLower(codec) == "h264" {
 		if strings.ToLower(profile) == "high" && level > 41 {
@@ -388,22 +1211,30 @@ type PacketInfo struct {
 	DTS         float64 `json:"dts"`
 	Size        int     `json:"size"`
 	StreamIndex int     `json:"stream_index"`
+	CodecType   string  `json:"codec_type,omitempty"` // "video", "audio", "cover_art", ...
 	Flags       string  `json:"flags,omitempty"`
 	Duration    float64 `json:"duration,omitempty"`
+	PTSMissing  bool    `json:"pts_missing,omitempty"` // ffprobe reported no PTS; PTS is 0
 }
 
 // FrameInfo represents a media frame
 type FrameInfo struct {
-	MediaType   string  `json:"media_type"`
-	StreamIndex int     `json:"stream_index"`
-	KeyFrame    bool    `json:"key_frame"`
-	PTS         float64 `json:"pts"`
-	DTS         float64 `json:"dts"`
-	Duration    float64 `json:"duration"`
-	Size        int     `json:"size"`
-	PixFmt      string  `json:"pix_fmt,omitempty"`
-	PictType    string  `json:"pict_type,omitempty"`
-	CodedNumber int     `json:"coded_picture_number,omitempty"`
+	MediaType       string  `json:"media_type"`
+	StreamIndex     int     `json:"stream_index"`
+	KeyFrame        bool    `json:"key_frame"`
+	PTS             float64 `json:"pts"`
+	DTS             float64 `json:"dts"`
+	Duration        float64 `json:"duration"`
+	Size            int     `json:"size"`
+	PixFmt          string  `json:"pix_fmt,omitempty"`
+	PictType        string  `json:"pict_type,omitempty"`
+	CodedNumber     int     `json:"coded_picture_number,omitempty"`
+	RepeatPict      int     `json:"repeat_pict,omitempty"` // Extra fields to display, per ffprobe repeat_pict
+	InterlacedFrame bool    `json:"interlaced_frame,omitempty"`
+	TopFieldFirst   bool    `json:"top_field_first,omitempty"`
+	Width           int     `json:"width,omitempty"`
+	Height          int     `json:"height,omitempty"`
+	AttachedPic     bool    `json:"attached_pic,omitempty"` // Frame of a cover art stream, skipped by video checks
 }
 
 // BitratePoint represents a bitrate measurement at a specific time
@@ -415,40 +1246,264 @@ type BitratePoint struct {
 
 // GenerateBitrateTimeline creates bitrate timeline data from packets
 func GenerateBitrateTimeline(packets []PacketInfo, windowSize float64) []BitratePoint {
-	if len(packets) == 0 || windowSize <= 0 {
+	return bitrateSeries(packets, windowSize, "total")
+}
+
+// GenerateBitrateTimelinePerStream creates a "total" series over all packets
+// followed by "video" and "audio" series, each summing the packets of every
+// stream of that codec type. Series without packets are left out.
+func GenerateBitrateTimelinePerStream(packets []PacketInfo, windowSize float64) []BitratePoint {
+	if windowSize <= 0 {
+		return nil
+	}
+	start, ok := bitrateStart(packets)
+	if !ok {
 		return nil
 	}
 
-	points := make([]BitratePoint, 0)
-	currentWindow := 0.0
-	windowBytes := 0
+	// All series share the windows of the total so their points line up
+	points := bitratePoints(packets, start, windowSize, "total")
+	for _, codecType := range []string{"video", "audio"} {
+		var typed []PacketInfo
+		for _, packet := range packets {
+			if packet.CodecType == codecType {
+				typed = append(typed, packet)
+			}
+		}
+		if len(typed) > 0 {
+			points = append(points, bitratePoints(typed, start, windowSize, codecType)...)
+		}
+	}
+	return points
+}
 
+// bitrateSeries windows packets into bitrate points of the given type,
+// keyed off the earliest packet PTS
+func bitrateSeries(packets []PacketInfo, windowSize float64, seriesType string) []BitratePoint {
+	if windowSize <= 0 {
+		return nil
+	}
+	start, ok := bitrateStart(packets)
+	if !ok {
+		return nil
+	}
+	return bitratePoints(packets, start, windowSize, seriesType)
+}
+
+// bitratePoints turns the windows of packets starting at start into bitrate
+// points. Windows inside a gap are kept with a zero bitrate so the gap
+// stays visible.
+func bitratePoints(packets []PacketInfo, start, windowSize float64, seriesType string) []BitratePoint {
+	windows := bitrateWindows(packets, start, windowSize)
+	if len(windows) == 0 {
+		return nil
+	}
+	points := make([]BitratePoint, len(windows))
+	for n, bytes := range windows {
+		points[n] = BitratePoint{
+			Time:    start + float64(n)*windowSize,
+			Bitrate: float64(bytes) * 8 / windowSize,
+			Type:    seriesType,
+		}
+	}
+	return points
+}
+
+// maxBitrateWindows caps the number of bitrate windows, so a bogus PTS far
+// past the rest cannot allocate an endless run of empty windows
+const maxBitrateWindows = 100000
+
+// bitrateStart returns the earliest PTS of the packets that have one, the
+// origin of the bitrate windows. ok is false when no packet has a PTS.
+func bitrateStart(packets []PacketInfo) (start float64, ok bool) {
 	for _, packet := range packets {
-		if packet.PTS > currentWindow+windowSize {
-			if windowBytes > 0 {
-				bitrate := float64(windowBytes) * 8 / windowSize
-				points = append(points, BitratePoint{
-					Time:    currentWindow,
-					Bitrate: bitrate,
-					Type:    "total",
-				})
-			}
-			currentWindow += windowSize
-			windowBytes = packet.Size
+		if packet.PTSMissing {
+			continue
+		}
+		if !ok || packet.PTS < start {
+			start, ok = packet.PTS, true
+		}
+	}
+	return start, ok
+}
+
+// bitrateWindows sums packet sizes into contiguous windows
+// [start+n*windowSize, start+(n+1)*windowSize), attributing each packet by
+// its PTS regardless of the order packets arrive in. Windows without packets
+// are zero. Packets without a PTS, before start or beyond
+// maxBitrateWindows are left out.
+func bitrateWindows(packets []PacketInfo, start, windowSize float64) []int {
+	var windows []int
+	for _, packet := range packets {
+		if packet.PTSMissing || packet.PTS < start {
+			continue
+		}
+		n := int(math.Floor((packet.PTS - start) / windowSize))
+		if n >= maxBitrateWindows {
+			continue
+		}
+		for len(windows) <= n {
+			windows = append(windows, 0)
+		}
+		windows[n] += packet.Size
+	}
+	return windows
+}
+
+// QualitySecond aggregates the video frames whose PTS falls within one second
+type QualitySecond struct {
+	Second    int   `json:"second"`
+	Frames    int   `json:"frames"`
+	KeyFrames int   `json:"key_frames"`
+	Bytes     int64 `json:"bytes"`
+	IFrames   int   `json:"i_frames"`
+	PFrames   int   `json:"p_frames"`
+	BFrames   int   `json:"b_frames"`
+}
+
+// GenerateQualityTimeline aggregates video frames into per-second buckets.
+// Seconds without frames are included with zero counts so gaps stay visible.
+func GenerateQualityTimeline(frames []FrameInfo) []QualitySecond {
+	buckets := make(map[int]*QualitySecond)
+	first, last := 0, 0
+	for _, frame := range frames {
+		if frame.MediaType != "video" || frame.AttachedPic {
+			continue
+		}
+
+		second := int(math.Floor(frame.PTS))
+		if len(buckets) == 0 || second < first {
+			first = second
+		}
+		if len(buckets) == 0 || second > last {
+			last = second
+		}
+
+		bucket, ok := buckets[second]
+		if !ok {
+			bucket = &QualitySecond{Second: second}
+			buckets[second] = bucket
+		}
+		bucket.Frames++
+		bucket.Bytes += int64(frame.Size)
+		if frame.KeyFrame {
+			bucket.KeyFrames++
+		}
+		switch frame.PictType {
+		case "I":
+			bucket.IFrames++
+		case "P":
+			bucket.PFrames++
+		case "B":
+			bucket.BFrames++
+		}
+	}
+	if len(buckets) == 0 {
+		return nil
+	}
+
+	timeline := make([]QualitySecond, 0, last-first+1)
+	for second := first; second <= last; second++ {
+		if bucket, ok := buckets[second]; ok {
+			timeline = append(timeline, *bucket)
 		} else {
-			windowBytes += packet.Size
+			timeline = append(timeline, QualitySecond{Second: second})
 		}
 	}
+	return timeline
+}
 
-	// Add final window
-	if windowBytes > 0 {
-		bitrate := float64(windowBytes) * 8 / windowSize
-		points = append(points, BitratePoint{
-			Time:    currentWindow,
-			Bitrate: bitrate,
-			Type:    "total",
-		})
+// MeasuredStreamBitrate returns the average bitrate (bits per second) of one
+// stream computed from its packet sizes over the time they span, and that
+// span in seconds. Packets without a PTS are left out.
+func MeasuredStreamBitrate(packets []PacketInfo, streamIndex int) (float64, float64) {
+	var total int64
+	first, last := math.Inf(1), math.Inf(-1)
+	for _, packet := range packets {
+		if packet.StreamIndex != streamIndex || packet.PTSMissing {
+			continue
+		}
+		total += int64(packet.Size)
+		first = math.Min(first, packet.PTS)
+		last = math.Max(last, packet.PTS+packet.Duration)
 	}
 
-	return points
+	span := last - first
+	if total == 0 || span <= 0 {
+		return 0, 0
+	}
+	return float64(total) * 8 / span, span
+}
+
+// DetectDeclaredBitrateMismatch flags a stream whose declared bitrate
+// differs by more than 20% from the bitrate measured over its packets
+func (d *Detector) DetectDeclaredBitrateMismatch(declared int64, measured float64, span float64, streamIndex int) {
+	if declared <= 0 || measured <= 0 {
+		return
+	}
+
+	deviation := (measured - float64(declared)) / float64(declared)
+	if math.Abs(deviation) <= 0.2 {
+		return
+	}
+
+	d.addProblem(Problem{
+		Severity:    SeverityInfo,
+		Category:    CategoryBitrate,
+		Code:        "DECLARED_BITRATE_MISMATCH",
+		Message:     fmt.Sprintf("Declared bitrate differs from the measured bitrate by %+.0f%%", deviation*100),
+		Details:     fmt.Sprintf("Declared: %.0f kbps, measured: %.0f kbps over %.1fs of packets", float64(declared)/1000, measured/1000, span),
+		Suggestion:  "The bitrate metadata may be stale (e.g. after editing or remuxing); re-mux to refresh it",
+		StreamIndex: streamIndex,
+	})
+}
+
+// PeakRollingBitrate returns the highest bitrate (bits per second) observed
+// over any window of the given length, sliding packet by packet rather than
+// over fixed buckets. When the packets span less than one window, the
+// bitrate over the whole span is returned.
+func PeakRollingBitrate(packets []PacketInfo, window float64) float64 {
+	if window <= 0 {
+		return 0
+	}
+
+	// Packets without a PTS cannot be placed in any window
+	sorted := make([]PacketInfo, 0, len(packets))
+	for _, packet := range packets {
+		if !packet.PTSMissing {
+			sorted = append(sorted, packet)
+		}
+	}
+	if len(sorted) == 0 {
+		return 0
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].PTS < sorted[j].PTS })
+
+	span := sorted[len(sorted)-1].PTS + sorted[len(sorted)-1].Duration - sorted[0].PTS
+	if span <= 0 {
+		return 0
+	}
+	if span < window {
+		total := 0
+		for _, packet := range sorted {
+			total += packet.Size
+		}
+		return float64(total) * 8 / span
+	}
+
+	peakBytes := 0
+	windowBytes := 0
+	start := 0
+	for _, packet := range sorted {
+		windowBytes += packet.Size
+		for packet.PTS-sorted[start].PTS >= window {
+			windowBytes -= sorted[start].Size
+			start++
+		}
+		if windowBytes > peakBytes {
+			peakBytes = windowBytes
+		}
+	}
+
+	return float64(peakBytes) * 8 / window
 }
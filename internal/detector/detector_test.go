@@ -0,0 +1,152 @@
+package detector
+
+import "testing"
+
+// countCode returns how many problems carry code
+func countCode(problems []Problem, code string) int {
+	n := 0
+	for _, problem := range problems {
+		if problem.Code == code {
+			n++
+		}
+	}
+	return n
+}
+
+func TestDetectKeyframeIssuesIgnoresAudio(t *testing.T) {
+	var frames []FrameInfo
+	// 2s GOPs at 25 fps, interleaved with audio frames that are all
+	// keyframes, and a B-frame style decode order that puts one keyframe
+	// ahead of its predecessor's last frame
+	for i := 0; i < 250; i++ {
+		pts := float64(i) * 0.04
+		frames = append(frames, FrameInfo{MediaType: "video", StreamIndex: 0, KeyFrame: i%50 == 0, PTS: pts})
+		frames = append(frames, FrameInfo{MediaType: "audio", StreamIndex: 1, KeyFrame: true, PTS: pts + 0.013})
+	}
+	frames[200], frames[198] = frames[198], frames[200]
+
+	d := New()
+	d.DetectKeyframeIssues(frames)
+	if n := countCode(d.GetProblems(), "IRREGULAR_KEYFRAME_INTERVAL"); n != 0 {
+		t.Errorf("got %d IRREGULAR_KEYFRAME_INTERVAL problems, want 0: %+v", n, d.GetProblems())
+	}
+	if n := countCode(d.GetProblems(), "NO_KEYFRAMES"); n != 0 {
+		t.Errorf("got NO_KEYFRAMES for a stream with 5 keyframes")
+	}
+}
+
+func TestDetectKeyframeIssuesFlagsIrregularVideo(t *testing.T) {
+	var frames []FrameInfo
+	for _, pts := range []float64{0, 2, 4, 6, 12, 14, 16} {
+		frames = append(frames, FrameInfo{MediaType: "video", KeyFrame: true, PTS: pts})
+	}
+
+	d := New()
+	d.DetectKeyframeIssues(frames)
+	if n := countCode(d.GetProblems(), "IRREGULAR_KEYFRAME_INTERVAL"); n != 1 {
+		t.Errorf("got %d IRREGULAR_KEYFRAME_INTERVAL problems, want 1", n)
+	}
+}
+
+func TestDetectVideoProblemsNonstandardFrameRate(t *testing.T) {
+	tests := []struct {
+		frameRate string
+		fps       float64
+		want      int
+	}{
+		{"30000/1001", 30000.0 / 1001, 0},
+		{"60000/2002", 60000.0 / 2002, 0}, // Same fraction, not reduced
+		{"2997/100", 29.97, 1},
+		{"25/1", 25, 0},
+		{"0/0", 0, 0},
+	}
+	for _, tt := range tests {
+		d := New()
+		d.DetectVideoProblems(VideoParams{Width: 1920, Height: 1080, FrameRate: tt.frameRate, FPS: tt.fps})
+		if n := countCode(d.GetProblems(), "NONSTANDARD_FRAME_RATE"); n != tt.want {
+			t.Errorf("%s: got %d NONSTANDARD_FRAME_RATE problems, want %d", tt.frameRate, n, tt.want)
+		}
+	}
+}
+
+func TestDetectUndefinedSAR(t *testing.T) {
+	tests := []struct {
+		sar           string
+		width, height int
+		want          int
+	}{
+		{"0:1", 1920, 1080, 1},
+		{"1:1", 1920, 1080, 0},
+		{"", 720, 576, 1},
+		{"", 1920, 1080, 0},
+		{"16:15", 720, 576, 0},
+		{"bogus", 1920, 1080, 0},
+	}
+	for _, tt := range tests {
+		d := New()
+		d.DetectUndefinedSAR(tt.sar, tt.width, tt.height, 0)
+		if n := countCode(d.GetProblems(), "UNDEFINED_SAR"); n != tt.want {
+			t.Errorf("%q at %dx%d: got %d UNDEFINED_SAR problems, want %d", tt.sar, tt.width, tt.height, n, tt.want)
+		}
+	}
+}
+
+func TestDetectUniformFrameSizesMainStreamOnly(t *testing.T) {
+	var frames []FrameInfo
+	for i := 0; i < 60; i++ {
+		// Cover art and a second angle with constant P-frame sizes must not
+		// be pooled with the main stream, whose sizes vary naturally
+		frames = append(frames,
+			FrameInfo{MediaType: "video", StreamIndex: 2, AttachedPic: true, PictType: "P", Size: 5000},
+			FrameInfo{MediaType: "video", StreamIndex: 1, PictType: "P", Size: 4000 + (i%7)*300},
+			FrameInfo{MediaType: "video", StreamIndex: 3, PictType: "P", Size: 5000},
+		)
+	}
+
+	d := New()
+	d.DetectUniformFrameSizes(frames)
+	if n := countCode(d.GetProblems(), "UNIFORM_FRAME_SIZES"); n != 0 {
+		t.Errorf("got %d UNIFORM_FRAME_SIZES problems for a varying main stream, want 0", n)
+	}
+
+	for i := range frames {
+		if frames[i].StreamIndex == 1 {
+			frames[i].Size = 4000
+		}
+	}
+	d = New()
+	d.DetectUniformFrameSizes(frames)
+	problems := d.GetProblems()
+	if len(problems) != 1 || problems[0].Code != "UNIFORM_FRAME_SIZES" || problems[0].StreamIndex != 1 {
+		t.Errorf("got %+v, want one UNIFORM_FRAME_SIZES problem on stream 1", problems)
+	}
+}
+
+func TestFrameChecksSkipCoverArt(t *testing.T) {
+	// A cover art stream whose frames would trip every check: repeated
+	// with the same size and PTS, interlaced, and numbered far apart
+	var frames []FrameInfo
+	for i := 0; i < 20; i++ {
+		frames = append(frames, FrameInfo{
+			MediaType:       "video",
+			StreamIndex:     1,
+			AttachedPic:     true,
+			Size:            30000,
+			InterlacedFrame: true,
+			CodedNumber:     i * 10,
+		})
+	}
+
+	checks := map[string]func(*Detector, []FrameInfo){
+		"DUPLICATE_FRAMES":            (*Detector).DetectDuplicateFrames,
+		"CODED_NUMBER_COUNT_MISMATCH": (*Detector).DetectCodedNumberMismatch,
+		"INTERLACED_CONTENT":          (*Detector).DetectInterlacing,
+	}
+	for code, check := range checks {
+		d := New()
+		check(d, frames)
+		if n := countCode(d.GetProblems(), code); n != 0 {
+			t.Errorf("got %d %s problems for cover art, want 0", n, code)
+		}
+	}
+}
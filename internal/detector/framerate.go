@@ -0,0 +1,237 @@
+package detector
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// DetectFrameDurationVariance flags CFR video whose frames do not all share
+// the nominal frame duration, which usually means dropped or duplicated
+// frames. Streams whose average rate differs from the nominal rate are
+// treated as intentionally VFR and skipped.
+func (d *Detector) DetectFrameDurationVariance(frames []FrameInfo, streamIndex int, nominalRate, avgRate float64) {
+	if nominalRate <= 0 || avgRate <= 0 {
+		return
+	}
+	// Declared VFR: the container already admits the rate varies
+	if math.Abs(nominalRate-avgRate)/nominalRate > 0.001 {
+		return
+	}
+
+	var durations []float64
+	for _, frame := range frames {
+		if frame.MediaType == "video" && frame.StreamIndex == streamIndex && frame.Duration > 0 {
+			durations = append(durations, frame.Duration)
+		}
+	}
+	// The final frame's duration is often truncated by the muxer
+	if len(durations) > 1 {
+		durations = durations[:len(durations)-1]
+	}
+	if len(durations) < 10 {
+		return
+	}
+
+	// Durations within half a millisecond of nominal are timestamp rounding
+	nominal := 1.0 / nominalRate
+	const tolerance = 0.0005
+
+	counts := make(map[float64]int)
+	deviant := 0
+	for _, duration := range durations {
+		// Bucket to 0.1ms so rounding noise doesn't create distinct entries
+		bucket := math.Round(duration*10000) / 10000
+		counts[bucket]++
+		if math.Abs(duration-nominal) > tolerance {
+			deviant++
+		}
+	}
+	if deviant == 0 {
+		return
+	}
+
+	buckets := make([]float64, 0, len(counts))
+	for bucket := range counts {
+		buckets = append(buckets, bucket)
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		if counts[buckets[i]] != counts[buckets[j]] {
+			return counts[buckets[i]] > counts[buckets[j]]
+		}
+		return buckets[i] < buckets[j]
+	})
+
+	parts := make([]string, 0, 5)
+	for i, bucket := range buckets {
+		if i == 5 {
+			parts = append(parts, fmt.Sprintf("... %d more", len(buckets)-5))
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%.4fs x%d", bucket, counts[bucket]))
+	}
+
+	d.addProblem(Problem{
+		Severity:    SeverityWarning,
+		Category:    CategoryFrameRate,
+		Code:        "FRAME_DURATION_VARIANCE",
+		Message:     fmt.Sprintf("%d of %d frames deviate from the nominal %.4fs duration of this CFR stream", deviant, len(durations), nominal),
+		Details:     fmt.Sprintf("Distinct durations: %s", strings.Join(parts, ", ")),
+		Suggestion:  "Frames may have been dropped or duplicated; check the capture or encode pipeline",
+		StreamIndex: streamIndex,
+	})
+}
+
+// DetectVariableFrameRate flags video whose average frame rate differs from
+// the nominal (r_frame_rate) one by more than the configured percentage,
+// which usually means variable frame rate or dropped frames. A nominal rate
+// of exactly twice the average is field rate on interlaced video, not VFR.
+func (d *Detector) DetectVariableFrameRate(video VideoParams) {
+	if video.FPS <= 0 || video.AvgFPS <= 0 {
+		return
+	}
+	if math.Abs(video.FPS-2*video.AvgFPS)/video.FPS < 0.001 {
+		return
+	}
+
+	diff := math.Abs(video.FPS-video.AvgFPS) / video.FPS * 100
+	if diff <= d.config.VFRThresholdPercent {
+		return
+	}
+
+	d.addProblem(Problem{
+		Severity:    SeverityWarning,
+		Category:    CategoryFrameRate,
+		Code:        "VARIABLE_FRAME_RATE",
+		Message:     fmt.Sprintf("Average frame rate differs from the nominal rate by %.1f%%", diff),
+		Details:     fmt.Sprintf("r_frame_rate: %s (%.3f fps), avg_frame_rate: %s (%.3f fps)", video.FrameRate, video.FPS, video.AvgFrameRate, video.AvgFPS),
+		Suggestion:  "Variable frame rate or dropped frames cause sync problems in editors; convert to constant frame rate (e.g. ffmpeg -fps_mode cfr -r 30000/1001)",
+		StreamIndex: video.Index,
+	})
+}
+
+// DetectDuplicateFrames flags video frames that repeat the previous frame of
+// their stream: the same size with an identical or near-zero PTS delta, as
+// left behind when a transcode pads to a target frame rate. Frames signalling
+// repeat_pict (soft pulldown) are counted and reported alongside, since they
+// also display a picture longer than one frame.
+func (d *Detector) DetectDuplicateFrames(frames []FrameInfo) {
+	// PTS deltas at or below this are treated as no time passing
+	const nearZero = 0.001
+
+	type duplicates struct {
+		count, repeatPict int
+		first, last       float64
+	}
+	byStream := make(map[int]*duplicates)
+	order := make([]int, 0)
+	previous := make(map[int]FrameInfo)
+
+	for _, frame := range frames {
+		if strings.ToLower(frame.MediaType) != "video" || frame.AttachedPic {
+			continue
+		}
+		s, ok := byStream[frame.StreamIndex]
+		if !ok {
+			s = &duplicates{}
+			byStream[frame.StreamIndex] = s
+			order = append(order, frame.StreamIndex)
+		}
+		if frame.RepeatPict > 0 {
+			s.repeatPict++
+		}
+
+		prev, seen := previous[frame.StreamIndex]
+		previous[frame.StreamIndex] = frame
+		if !seen || frame.Size <= 0 || frame.Size != prev.Size || math.Abs(frame.PTS-prev.PTS) > nearZero {
+			continue
+		}
+		if s.count == 0 {
+			s.first = prev.PTS
+		}
+		s.count++
+		s.last = frame.PTS
+	}
+
+	for _, streamIndex := range order {
+		s := byStream[streamIndex]
+		if s.count == 0 {
+			continue
+		}
+		details := fmt.Sprintf("%d duplicate frame(s) between %.3fs and %.3fs", s.count, s.first, s.last)
+		if s.repeatPict > 0 {
+			details += fmt.Sprintf("; %d frame(s) also signal repeat_pict", s.repeatPict)
+		}
+		d.addProblem(Problem{
+			Severity:    SeverityWarning,
+			Category:    CategoryFrameRate,
+			Code:        "DUPLICATE_FRAMES",
+			Message:     fmt.Sprintf("Duplicate frames detected in stream %d", streamIndex),
+			Details:     details,
+			Timestamp:   s.first,
+			StreamIndex: streamIndex,
+			Suggestion:  "Encode at the source frame rate instead of padding to a target rate, or drop duplicates (e.g. ffmpeg -vf mpdecimate)",
+		})
+	}
+}
+
+// DetectCodedNumberMismatch compares the span of coded_picture_number values
+// with the number of video frames probed per stream. Decoders number every
+// picture they decode, so a span larger than the frame count points at
+// dropped frames and a smaller one at duplicated frames. Streams whose
+// decoder leaves the number at zero are skipped.
+func (d *Detector) DetectCodedNumberMismatch(frames []FrameInfo) {
+	type span struct {
+		min, max, count int
+	}
+	byStream := make(map[int]*span)
+	order := make([]int, 0)
+
+	for _, frame := range frames {
+		if strings.ToLower(frame.MediaType) != "video" || frame.AttachedPic {
+			continue
+		}
+		s, ok := byStream[frame.StreamIndex]
+		if !ok {
+			s = &span{min: frame.CodedNumber, max: frame.CodedNumber}
+			byStream[frame.StreamIndex] = s
+			order = append(order, frame.StreamIndex)
+		}
+		if frame.CodedNumber < s.min {
+			s.min = frame.CodedNumber
+		}
+		if frame.CodedNumber > s.max {
+			s.max = frame.CodedNumber
+		}
+		s.count++
+	}
+
+	for _, streamIndex := range order {
+		s := byStream[streamIndex]
+		if s.max == 0 {
+			continue
+		}
+		expected := s.max - s.min + 1
+		diff := expected - s.count
+		// Allow a frame or two, or 1%, for decoder delay at the edges
+		tolerance := int(math.Max(2, float64(s.count)/100))
+		if diff >= -tolerance && diff <= tolerance {
+			continue
+		}
+
+		kind := "dropped"
+		if diff < 0 {
+			kind = "duplicated"
+		}
+		d.addProblem(Problem{
+			Severity:    SeverityInfo,
+			Category:    CategoryFrameRate,
+			Code:        "CODED_NUMBER_COUNT_MISMATCH",
+			Message:     fmt.Sprintf("Coded picture numbers do not match the frame count in stream %d, possibly %s frames", streamIndex, kind),
+			Details:     fmt.Sprintf("coded_picture_number span: %d (%d-%d), frames probed: %d", expected, s.min, s.max, s.count),
+			StreamIndex: streamIndex,
+			Suggestion:  "Check the source for dropped or duplicated frames",
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package detector
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// maxStreamingPyramidDepth is the deepest B-frame pyramid tolerated for
+// low-latency streaming. Each level adds a frame of reordering delay.
+const maxStreamingPyramidDepth = 2
+
+// longestBFrameRun returns the longest run of consecutive B-frames in the
+// stream's pict-type sequence, in PTS order
+func longestBFrameRun(frames []FrameInfo, streamIndex int) int {
+	longest, run := 0, 0
+	for _, frame := range videoFrames(frames, streamIndex) {
+		if frame.PictType == "B" {
+			run++
+			if run > longest {
+				longest = run
+			}
+		} else {
+			run = 0
+		}
+	}
+	return longest
+}
+
+// pyramidDepth estimates the B-frame pyramid depth of a run of n
+// consecutive B-frames: a hierarchical GOP of n B-frames needs
+// ceil(log2(n+1)) reference levels
+func pyramidDepth(n int) int {
+	if n <= 0 {
+		return 0
+	}
+	return int(math.Ceil(math.Log2(float64(n + 1))))
+}
+
+// DetectBFramePyramid estimates the B-frame pyramid depth from the
+// pict-type sequence and, in streaming mode, flags HIGH_BFRAME_PYRAMID when
+// the reordering delay it implies is too high for low-latency delivery
+func (d *Detector) DetectBFramePyramid(frames []FrameInfo, streamIndex int, streaming bool) {
+	if !streaming {
+		return
+	}
+
+	run := longestBFrameRun(frames, streamIndex)
+	depth := pyramidDepth(run)
+	if depth <= maxStreamingPyramidDepth {
+		return
+	}
+
+	d.addProblem(Problem{
+		Severity:    SeverityWarning,
+		Category:    CategoryKeyframe,
+		Code:        "HIGH_BFRAME_PYRAMID",
+		Message:     fmt.Sprintf("Estimated B-frame pyramid depth of %d adds reordering latency", depth),
+		Details:     fmt.Sprintf("Longest run of consecutive B-frames: %d, estimated pyramid depth: %d (max %d for streaming)", run, depth, maxStreamingPyramidDepth),
+		Suggestion:  "For low-latency streaming, limit consecutive B-frames (e.g. x264 -bf 2 or -b-pyramid none) or disable B-frames",
+		StreamIndex: streamIndex,
+	})
+}
+
+// gopSizeDeviation is the fractional deviation from the median GOP frame
+// count that flags a GOP as irregular
+const gopSizeDeviation = 0.5
+
+// GOPInfo summarizes one group of pictures: a keyframe and the frames up to
+// the next keyframe
+type GOPInfo struct {
+	StartTime  float64 `json:"start_time"`
+	EndTime    float64 `json:"end_time"` // PTS of the last frame in the GOP
+	Duration   float64 `json:"duration"` // Up to the next keyframe, or the end of the last frame
+	FrameCount int     `json:"frame_count"`
+	IFrames    int     `json:"i_frames"`
+	PFrames    int     `json:"p_frames"`
+	BFrames    int     `json:"b_frames"`
+	Bytes      int64   `json:"bytes"`
+}
+
+// mainVideoStream returns the index of the first video stream among frames
+// that is not cover art, or -1 when there is none
+func mainVideoStream(frames []FrameInfo) int {
+	for _, frame := range frames {
+		if strings.EqualFold(frame.MediaType, "video") && !frame.AttachedPic {
+			return frame.StreamIndex
+		}
+	}
+	return -1
+}
+
+// mainVideoFrames returns the frames of the main video stream sorted by PTS
+func mainVideoFrames(frames []FrameInfo) []FrameInfo {
+	streamIndex := mainVideoStream(frames)
+	if streamIndex < 0 {
+		return nil
+	}
+	return videoFrames(frames, streamIndex)
+}
+
+// videoFrames returns the video frames of one stream sorted by PTS.
+// ffprobe lists decoded frames, which normally come out in presentation
+// order, but the order is not trusted: sorting here gives every GOP,
+// keyframe and B-frame check the same PTS order.
+func videoFrames(frames []FrameInfo, streamIndex int) []FrameInfo {
+	video := make([]FrameInfo, 0, len(frames))
+	for _, frame := range frames {
+		if strings.EqualFold(frame.MediaType, "video") && frame.StreamIndex == streamIndex {
+			video = append(video, frame)
+		}
+	}
+	sort.SliceStable(video, func(i, j int) bool { return video[i].PTS < video[j].PTS })
+	return video
+}
+
+// BuildGOPs splits the frames of the first video stream, in PTS order, into
+// GOPs, starting a new GOP at every keyframe. Frames before the first
+// keyframe form a GOP of their own.
+func BuildGOPs(frames []FrameInfo) []GOPInfo {
+	video := mainVideoFrames(frames)
+	if len(video) == 0 {
+		return nil
+	}
+
+	var gops []GOPInfo
+	var current *GOPInfo
+	var last FrameInfo
+	for _, frame := range video {
+		if current == nil || frame.KeyFrame && current.FrameCount > 0 {
+			if current != nil {
+				current.Duration = frame.PTS - current.StartTime
+			}
+			gops = append(gops, GOPInfo{StartTime: frame.PTS})
+			current = &gops[len(gops)-1]
+		}
+
+		switch frame.PictType {
+		case "I":
+			current.IFrames++
+		case "P":
+			current.PFrames++
+		case "B":
+			current.BFrames++
+		}
+		current.FrameCount++
+		current.Bytes += int64(frame.Size)
+		current.EndTime = frame.PTS
+		last = frame
+	}
+	current.Duration = last.PTS + last.Duration - current.StartTime
+	return gops
+}
+
+// DetectGOPProblems builds the GOP structure of the first video stream and
+// flags GOPs whose frame count differs wildly from the rest (scene-cut
+// keyframes, open GOPs) and GOPs longer than the configured maximum
+func (d *Detector) DetectGOPProblems(frames []FrameInfo) {
+	gops := BuildGOPs(frames)
+	if len(gops) == 0 {
+		return
+	}
+
+	streamIndex := mainVideoStream(frames)
+	d.detectGOPSizeVariation(gops, streamIndex)
+
+	longest, long := 0, 0
+	for i, gop := range gops {
+		if gop.Duration > d.config.MaxGOPDuration {
+			long++
+			if gop.Duration > gops[longest].Duration {
+				longest = i
+			}
+		}
+	}
+	if long > 0 {
+		gop := gops[longest]
+		d.addProblem(Problem{
+			Severity:    SeverityWarning,
+			Category:    CategoryKeyframe,
+			Code:        "LONG_GOP",
+			Message:     fmt.Sprintf("%d GOP(s) longer than %.1fs", long, d.config.MaxGOPDuration),
+			Details:     fmt.Sprintf("Longest GOP: %.2fs (%d frames) starting at %.3fs", gop.Duration, gop.FrameCount, gop.StartTime),
+			Timestamp:   gop.StartTime,
+			StreamIndex: streamIndex,
+			Suggestion:  "Insert keyframes more often (e.g. ffmpeg -g or -force_key_frames) so players can seek and switch renditions quickly",
+		})
+	}
+}
+
+// detectGOPSizeVariation compares the frame count of each complete GOP with
+// the median. The first GOP may start mid-stream and the last is usually
+// cut short by the frame limit, so only the GOPs between them are judged.
+func (d *Detector) detectGOPSizeVariation(gops []GOPInfo, streamIndex int) {
+	if len(gops) < 4 {
+		return
+	}
+	complete := gops[1 : len(gops)-1]
+
+	counts := make([]int, len(complete))
+	for i, gop := range complete {
+		counts[i] = gop.FrameCount
+	}
+	sort.Ints(counts)
+	median := float64(counts[len(counts)/2])
+	if median <= 0 {
+		return
+	}
+
+	irregular := 0
+	first := -1
+	for i, gop := range complete {
+		if math.Abs(float64(gop.FrameCount)-median)/median > gopSizeDeviation {
+			irregular++
+			if first < 0 {
+				first = i
+			}
+		}
+	}
+	if irregular == 0 {
+		return
+	}
+
+	d.addProblem(Problem{
+		Severity:    SeverityInfo,
+		Category:    CategoryKeyframe,
+		Code:        "GOP_SIZE_VARIATION",
+		Message:     fmt.Sprintf("%d of %d GOPs differ from the typical size of %d frames", irregular, len(complete), int(median)),
+		Details:     fmt.Sprintf("GOP sizes range from %d to %d frames; first irregular GOP has %d frames at %.3fs. Usually scene-cut keyframes or open GOPs.", counts[0], counts[len(counts)-1], complete[first].FrameCount, complete[first].StartTime),
+		Timestamp:   complete[first].StartTime,
+		StreamIndex: streamIndex,
+		Suggestion:  "For a fixed GOP, disable scene-cut keyframes (e.g. x264 -sc_threshold 0 with keyint equal to min-keyint)",
+	})
+}
@@ -0,0 +1,36 @@
+package detector
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildGOPsSortsByPTS(t *testing.T) {
+	var frames []FrameInfo
+	for i := 0; i < 8; i++ {
+		pictType := "P"
+		if i%4 == 0 {
+			pictType = "I"
+		}
+		frames = append(frames,
+			FrameInfo{MediaType: "video", StreamIndex: 0, KeyFrame: i%4 == 0, PictType: pictType, PTS: float64(i), Duration: 1, Size: 100},
+			FrameInfo{MediaType: "audio", StreamIndex: 1, KeyFrame: true, PTS: float64(i)},
+		)
+	}
+	want := []GOPInfo{
+		{StartTime: 0, EndTime: 3, Duration: 4, FrameCount: 4, IFrames: 1, PFrames: 3, Bytes: 400},
+		{StartTime: 4, EndTime: 7, Duration: 4, FrameCount: 4, IFrames: 1, PFrames: 3, Bytes: 400},
+	}
+	if got := BuildGOPs(frames); !reflect.DeepEqual(got, want) {
+		t.Fatalf("in order: got %+v, want %+v", got, want)
+	}
+
+	// The same frames listed out of order, with the second keyframe ahead
+	// of the frame before it
+	for i, j := range []int{0, 8, 6, 2, 4, 10, 14, 12} {
+		frames[i], frames[j] = frames[j], frames[i]
+	}
+	if got := BuildGOPs(frames); !reflect.DeepEqual(got, want) {
+		t.Errorf("out of order: got %+v, want %+v", got, want)
+	}
+}
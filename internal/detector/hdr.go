@@ -0,0 +1,66 @@
+package detector
+
+import (
+	"fmt"
+	"strings"
+)
+
+// HDRMetadata is the HDR10 static metadata carried as stream side data
+type HDRMetadata struct {
+	MasteringDisplay bool    `json:"mastering_display"`       // SMPTE ST 2086 mastering display metadata present
+	MaxLuminance     float64 `json:"max_luminance,omitempty"` // Mastering display peak, cd/m²
+	MinLuminance     float64 `json:"min_luminance,omitempty"` // Mastering display black level, cd/m²
+	ContentLight     bool    `json:"content_light_level"`     // Content light level metadata present
+	MaxCLL           int     `json:"max_cll,omitempty"`       // Maximum content light level, cd/m²
+	MaxFALL          int     `json:"max_fall,omitempty"`      // Maximum frame-average light level, cd/m²
+}
+
+// Describe summarizes the metadata found, e.g. "mastering display 1000/0.0050
+// cd/m², MaxCLL 1000, MaxFALL 400"
+func (h *HDRMetadata) Describe() string {
+	if h == nil {
+		return "none"
+	}
+	parts := make([]string, 0, 2)
+	if h.MasteringDisplay {
+		parts = append(parts, fmt.Sprintf("mastering display %.0f/%.4f cd/m²", h.MaxLuminance, h.MinLuminance))
+	}
+	if h.ContentLight {
+		parts = append(parts, fmt.Sprintf("MaxCLL %d, MaxFALL %d", h.MaxCLL, h.MaxFALL))
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// IsPQTransfer reports whether an ffprobe color_transfer is SMPTE ST 2084
+// (PQ), the transfer function used by HDR10
+func IsPQTransfer(transfer string) bool {
+	return strings.EqualFold(transfer, "smpte2084")
+}
+
+// DetectHDR10LightLevel flags PQ video whose content light level metadata
+// (MaxCLL/MaxFALL) is missing or zero; some displays need it to tone-map
+func (d *Detector) DetectHDR10LightLevel(transfer string, hdr *HDRMetadata, streamIndex int) {
+	if !IsPQTransfer(transfer) {
+		return
+	}
+	if hdr != nil && hdr.ContentLight && hdr.MaxCLL > 0 && hdr.MaxFALL > 0 {
+		return
+	}
+
+	reason := "absent"
+	if hdr != nil && hdr.ContentLight {
+		reason = "zero"
+	}
+	d.addProblem(Problem{
+		Severity:    SeverityWarning,
+		Category:    CategoryCompatibility,
+		Code:        "HDR10_LIGHT_LEVEL_MISSING",
+		Message:     fmt.Sprintf("PQ (HDR10) video has %s MaxCLL/MaxFALL metadata", reason),
+		Details:     fmt.Sprintf("HDR metadata found: %s", hdr.Describe()),
+		Suggestion:  "Set content light levels when encoding (e.g. x265 --max-cll \"1000,400\") so displays can tone-map correctly",
+		StreamIndex: streamIndex,
+	})
+}
@@ -0,0 +1,76 @@
+package detector
+
+import (
+	"fmt"
+	"strings"
+)
+
+// interlacedFraction is the share of interlaced video frames from which a
+// stream is reported as interlaced rather than as having stray flagged frames
+const interlacedFraction = 0.2
+
+// DetectInterlacing warns when a significant fraction of a stream's video
+// frames are coded interlaced. Progressive delivery targets (web, mobile)
+// show combing artifacts on such content unless it is deinterlaced.
+func (d *Detector) DetectInterlacing(frames []FrameInfo) {
+	const minFrames = 10
+
+	type counts struct {
+		total, interlaced, topFieldFirst, repeatPict int
+		first                                        float64
+	}
+	byStream := make(map[int]*counts)
+	order := make([]int, 0)
+
+	for _, frame := range frames {
+		if strings.ToLower(frame.MediaType) != "video" || frame.AttachedPic {
+			continue
+		}
+		c, ok := byStream[frame.StreamIndex]
+		if !ok {
+			c = &counts{}
+			byStream[frame.StreamIndex] = c
+			order = append(order, frame.StreamIndex)
+		}
+		c.total++
+		if frame.RepeatPict > 0 {
+			c.repeatPict++
+		}
+		if frame.InterlacedFrame {
+			if c.interlaced == 0 {
+				c.first = frame.PTS
+			}
+			c.interlaced++
+			if frame.TopFieldFirst {
+				c.topFieldFirst++
+			}
+		}
+	}
+
+	for _, streamIndex := range order {
+		c := byStream[streamIndex]
+		if c.total < minFrames || float64(c.interlaced)/float64(c.total) < interlacedFraction {
+			continue
+		}
+
+		fieldOrder := "bottom field first"
+		if c.topFieldFirst*2 >= c.interlaced {
+			fieldOrder = "top field first"
+		}
+		details := fmt.Sprintf("%d of %d frames interlaced (%.0f%%), %s", c.interlaced, c.total, float64(c.interlaced)/float64(c.total)*100, fieldOrder)
+		if c.repeatPict > 0 {
+			details += fmt.Sprintf("; %d frames signal repeat_pict (telecine)", c.repeatPict)
+		}
+
+		d.addProblem(Problem{
+			Severity:    SeverityWarning,
+			Category:    CategoryFrameRate,
+			Code:        "INTERLACED_CONTENT",
+			Message:     fmt.Sprintf("Stream %d contains interlaced frames", streamIndex),
+			Details:     details,
+			Timestamp:   c.first,
+			StreamIndex: streamIndex,
+			Suggestion:  "Deinterlace for progressive delivery (e.g. ffmpeg -vf bwdif or yadif); inverse-telecine film sources instead",
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package detector
+
+import (
+	"fmt"
+	"strings"
+)
+
+// channelLayoutAliases maps channel layout spellings used by different
+// ffmpeg versions onto a single canonical name. Older releases report the
+// side-surround variants with an explicit "(side)" suffix while newer ones
+// drop it, and the unordered "N channels" form only appears since 5.1.
+var channelLayoutAliases = map[string]string{
+	"1 channels": "mono",
+	"1.0":        "mono",
+	"2 channels": "stereo",
+	"2.0":        "stereo",
+	"4.0(side)":  "quad(side)",
+	"5.0(side)":  "5.0",
+	"5.1(side)":  "5.1",
+	"6.0(side)":  "6.0",
+	"7.1(side)":  "7.1",
+}
+
+// channelLayoutChannels lists the channel count of canonical layouts
+var channelLayoutChannels = map[string]int{
+	"mono":           1,
+	"stereo":         2,
+	"2.1":            3,
+	"3.0":            3,
+	"3.0(back)":      3,
+	"4.0":            4,
+	"quad":           4,
+	"quad(side)":     4,
+	"3.1":            4,
+	"5.0":            5,
+	"5.0(back)":      5,
+	"4.1":            5,
+	"5.1":            6,
+	"5.1(back)":      6,
+	"6.0":            6,
+	"6.0(front)":     6,
+	"hexagonal":      6,
+	"6.1":            7,
+	"6.1(back)":      7,
+	"6.1(front)":     7,
+	"7.0":            7,
+	"7.0(front)":     7,
+	"7.1":            8,
+	"7.1(wide)":      8,
+	"7.1(wide-side)": 8,
+	"octagonal":      8,
+	"downmix":        2,
+}
+
+// NormalizeChannelLayout returns the canonical form of an ffprobe channel
+// layout string so layouts reported by different ffmpeg versions compare
+// equal
+func NormalizeChannelLayout(layout string) string {
+	normalized := strings.ToLower(strings.Join(strings.Fields(layout), " "))
+	normalized = strings.ReplaceAll(normalized, " (", "(")
+	if canonical, ok := channelLayoutAliases[normalized]; ok {
+		return canonical
+	}
+	return normalized
+}
+
+// SameChannelLayout reports whether two layout strings describe the same
+// channel arrangement
+func SameChannelLayout(a, b string) bool {
+	return NormalizeChannelLayout(a) == NormalizeChannelLayout(b)
+}
+
+// DetectChannelLayoutMismatch flags audio whose channel layout does not
+// match its channel count. Layouts are normalized first so version-specific
+// spellings such as "5.1(side)" are not reported.
+func (d *Detector) DetectChannelLayoutMismatch(layout string, channels, streamIndex int) {
+	if layout == "" || channels <= 0 {
+		return
+	}
+
+	normalized := NormalizeChannelLayout(layout)
+	expected, ok := channelLayoutChannels[normalized]
+	if !ok || expected == channels {
+		return
+	}
+
+	d.addProblem(Problem{
+		Severity:    SeverityWarning,
+		Category:    CategoryAudio,
+		Code:        "AUDIO_LAYOUT_MISMATCH",
+		Message:     fmt.Sprintf("Channel layout %s does not match %d channels", layout, channels),
+		Details:     fmt.Sprintf("Raw layout: %s, normalized: %s (%d channels)", layout, normalized, expected),
+		Suggestion:  "Re-mux or re-encode the audio with a channel layout that matches its channel count",
+		StreamIndex: streamIndex,
+	})
+}
@@ -0,0 +1,252 @@
+package detector
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// levelCapability describes the decoding limits of one codec level
+type levelCapability struct {
+	idc         int     // level as reported by ffprobe (e.g. 31 for H.264 3.1, 93 for HEVC 3.1)
+	name        string  // human-readable level name
+	maxLumaPs   int64   // max picture size in luma samples
+	maxLumaRate float64 // max luma sample rate (samples/s)
+}
+
+// codecLevels holds the level table for one codec, ordered from the least to
+// the most capable level
+type codecLevels struct {
+	displayName string
+	align       int // picture dimensions are coded in multiples of this
+	levels      []levelCapability
+}
+
+// h264Level converts an ITU-T H.264 Table A-1 entry, which is expressed in
+// 16x16 macroblocks, to luma samples
+func h264Level(idc int, name string, maxMBPS, maxFS int64) levelCapability {
+	return levelCapability{idc: idc, name: name, maxLumaPs: maxFS * 256, maxLumaRate: float64(maxMBPS * 256)}
+}
+
+// levelTables maps an ffprobe codec name to its level limits
+var levelTables = map[string]codecLevels{
+	"h264": {
+		displayName: "H.264",
+		align:       16,
+		levels: []levelCapability{
+			h264Level(10, "1", 1485, 99),
+			h264Level(9, "1b", 1485, 99),
+			h264Level(11, "1.1", 3000, 396),
+			h264Level(12, "1.2", 6000, 396),
+			h264Level(13, "1.3", 11880, 396),
+			h264Level(20, "2", 11880, 396),
+			h264Level(21, "2.1", 19800, 792),
+			h264Level(22, "2.2", 20250, 1620),
+			h264Level(30, "3", 40500, 1620),
+			h264Level(31, "3.1", 108000, 3600),
+			h264Level(32, "3.2", 216000, 5120),
+			h264Level(40, "4", 245760, 8192),
+			h264Level(41, "4.1", 245760, 8192),
+			h264Level(42, "4.2", 522240, 8704),
+			h264Level(50, "5", 589824, 22080),
+			h264Level(51, "5.1", 983040, 36864),
+			h264Level(52, "5.2", 2073600, 36864),
+			h264Level(60, "6", 4177920, 139264),
+			h264Level(61, "6.1", 8355840, 139264),
+			h264Level(62, "6.2", 16711680, 139264),
+		},
+	},
+	// ITU-T H.265 Table A.8 (Main tier sample rates)
+	"hevc": {
+		displayName: "HEVC",
+		align:       8,
+		levels: []levelCapability{
+			{30, "1", 36864, 552960},
+			{60, "2", 122880, 3686400},
+			{63, "2.1", 245760, 7372800},
+			{90, "3", 552960, 16588800},
+			{93, "3.1", 983040, 33177600},
+			{120, "4", 2228224, 66846720},
+			{123, "4.1", 2228224, 133693440},
+			{150, "5", 8912896, 267386880},
+			{153, "5.1", 8912896, 534773760},
+			{156, "5.2", 8912896, 1069547520},
+			{180, "6", 35651584, 1069547520},
+			{183, "6.1", 35651584, 2139095040},
+			{186, "6.2", 35651584, 4278190080},
+		},
+	},
+}
+
+// lookupLevels returns the level table for codec, if one is known
+func lookupLevels(codec string) (codecLevels, bool) {
+	codec = strings.ToLower(codec)
+	if codec == "h265" {
+		codec = "hevc"
+	}
+	table, ok := levelTables[codec]
+	return table, ok
+}
+
+// find returns the position of idc in the table, or -1
+func (t codecLevels) find(idc int) int {
+	for i, l := range t.levels {
+		if l.idc == idc {
+			return i
+		}
+	}
+	return -1
+}
+
+// pictureSize returns the coded luma picture size and its coded dimensions
+func (t codecLevels) pictureSize(width, height int) (int64, int64, int64) {
+	w := int64((width + t.align - 1) / t.align * t.align)
+	h := int64((height + t.align - 1) / t.align * t.align)
+	return w * h, w, h
+}
+
+// fitsPicture reports whether a level can hold a width x height picture.
+// Besides the total picture size, each dimension is limited to
+// sqrt(8 * MaxLumaPs) so that extreme aspect ratios cannot cheat the limit.
+func (t codecLevels) fitsPicture(l levelCapability, width, height int) bool {
+	size, w, h := t.pictureSize(width, height)
+	maxDim := int64(math.Sqrt(float64(l.maxLumaPs) * 8))
+	return size <= l.maxLumaPs && w <= maxDim && h <= maxDim
+}
+
+// required returns the position of the lowest level able to decode
+// width x height at frameRate, or -1 if no level suffices
+func (t codecLevels) required(width, height int, frameRate float64) int {
+	size, _, _ := t.pictureSize(width, height)
+	for i, l := range t.levels {
+		if t.fitsPicture(l, width, height) && float64(size)*frameRate <= l.maxLumaRate {
+			return i
+		}
+	}
+	return -1
+}
+
+// DetectLevelCapability checks a stream's declared level against the limits
+// of the level tables. A picture larger than the level allows is reported as
+// RESOLUTION_EXCEEDS_LEVEL; otherwise a level that cannot sustain the
+// resolution at the stream's frame rate is reported as
+// LEVEL_TOO_LOW_FOR_RESOLUTION.
+func (d *Detector) DetectLevelCapability(codec string, level, width, height int, frameRate float64) {
+	table, ok := lookupLevels(codec)
+	if !ok || level <= 0 || width <= 0 || height <= 0 {
+		return
+	}
+
+	declared := table.find(level)
+	if declared < 0 {
+		return
+	}
+	declaredLevel := table.levels[declared]
+
+	if !table.fitsPicture(declaredLevel, width, height) {
+		maxDim := int64(math.Sqrt(float64(declaredLevel.maxLumaPs) * 8))
+		d.addProblem(Problem{
+			Severity:   SeverityWarning,
+			Category:   CategoryCodec,
+			Code:       "RESOLUTION_EXCEEDS_LEVEL",
+			Message:    fmt.Sprintf("%dx%d exceeds the maximum picture size of %s level %s", width, height, table.displayName, declaredLevel.name),
+			Details:    fmt.Sprintf("Resolution: %dx%d (%d luma samples), level %s max: %d luma samples, %d pixels per dimension", width, height, width*height, declaredLevel.name, declaredLevel.maxLumaPs, maxDim),
+			Suggestion: "The level field is likely mislabeled; re-encode at a conformant level or lower the resolution",
+		})
+		return
+	}
+
+	if frameRate <= 0 {
+		return
+	}
+
+	required := table.required(width, height, frameRate)
+	if required >= 0 && required <= declared {
+		return
+	}
+
+	requiredName := "above " + table.levels[len(table.levels)-1].name
+	if required >= 0 {
+		requiredName = table.levels[required].name
+	}
+	size, _, _ := table.pictureSize(width, height)
+
+	d.addProblem(Problem{
+		Severity:   SeverityWarning,
+		Category:   CategoryCodec,
+		Code:       "LEVEL_TOO_LOW_FOR_RESOLUTION",
+		Message:    fmt.Sprintf("%s level %s is too low for %dx%d @ %.2f fps", table.displayName, declaredLevel.name, width, height, frameRate),
+		Details:    fmt.Sprintf("Declared level: %s, required level: %s (%.0f luma samples/s, level max: %.0f)", declaredLevel.name, requiredName, float64(size)*frameRate, declaredLevel.maxLumaRate),
+		Suggestion: fmt.Sprintf("Re-encode or re-signal the stream with level %s or higher; strict decoders may reject it", requiredName),
+	})
+}
+
+// mpeg2Levels maps ffprobe's MPEG-2 level_idc to its level name
+var mpeg2Levels = map[int]string{4: "High", 6: "High 1440", 8: "Main", 10: "Low"}
+
+var (
+	// profileNumericLevel matches a level embedded in a profile string, as
+	// written by MediaInfo-style tools: "High@L4.1", "Main@L5.1@High", "Level 3"
+	profileNumericLevel = regexp.MustCompile(`(?i)(?:@\s*L|\blevel\s*|\bL)(\d(?:\.\d)?b?)\b`)
+	// profileNamedLevel matches MPEG-2 style named levels: "Main@High"
+	profileNamedLevel = regexp.MustCompile(`(?i)@\s*(High 1440|High|Main|Low)\b`)
+)
+
+// levelName returns the display name of an ffprobe numeric level
+func levelName(codec string, level int) (string, bool) {
+	if strings.EqualFold(codec, "mpeg2video") {
+		name, ok := mpeg2Levels[level]
+		return name, ok
+	}
+	table, ok := lookupLevels(codec)
+	if !ok {
+		return "", false
+	}
+	i := table.find(level)
+	if i < 0 {
+		return "", false
+	}
+	return table.levels[i].name, true
+}
+
+// profileStringLevel extracts a level embedded in a profile string
+func profileStringLevel(codec, profile string) (string, bool) {
+	if strings.EqualFold(codec, "mpeg2video") {
+		if m := profileNamedLevel.FindStringSubmatch(profile); m != nil {
+			return m[1], true
+		}
+		return "", false
+	}
+	if m := profileNumericLevel.FindStringSubmatch(profile); m != nil {
+		return strings.TrimSuffix(m[1], ".0"), true
+	}
+	return "", false
+}
+
+// DetectProfileLevelConsistency flags streams whose profile string embeds a
+// level (e.g. "High@L4.1") that disagrees with the numeric level field
+func (d *Detector) DetectProfileLevelConsistency(codec, profile string, level, streamIndex int) {
+	if profile == "" || level <= 0 {
+		return
+	}
+
+	implied, ok := profileStringLevel(codec, profile)
+	if !ok {
+		return
+	}
+	declared, ok := levelName(codec, level)
+	if !ok || strings.EqualFold(implied, declared) {
+		return
+	}
+
+	d.addProblem(Problem{
+		Severity:    SeverityWarning,
+		Category:    CategoryCodec,
+		Code:        "PROFILE_LEVEL_INCONSISTENT",
+		Message:     fmt.Sprintf("Profile %q implies level %s but the stream declares level %s", profile, implied, declared),
+		Details:     fmt.Sprintf("Profile string: %s, numeric level: %d (%s)", profile, level, declared),
+		Suggestion:  "The stream is mislabeled; re-signal the level or re-encode so both fields agree",
+		StreamIndex: streamIndex,
+	})
+}
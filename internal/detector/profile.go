@@ -0,0 +1,206 @@
+package detector
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Profile describes the requirements of a delivery target. Detectors that
+// enforce deliverable specs only run when a profile is selected. Zero
+// values leave a requirement unchecked.
+type Profile struct {
+	Name            string `json:"name" yaml:"name"`
+	MinVideoStreams int    `json:"min_video_streams" yaml:"min_video_streams"`
+	MaxVideoStreams int    `json:"max_video_streams" yaml:"max_video_streams"` // 0 means no limit
+	MinAudioStreams int    `json:"min_audio_streams" yaml:"min_audio_streams"`
+	MaxAudioStreams int    `json:"max_audio_streams" yaml:"max_audio_streams"` // 0 means no limit
+	AudioSampleRate int    `json:"audio_sample_rate" yaml:"audio_sample_rate"` // Required sample rate in Hz; 0 accepts any
+	SquarePixels    bool   `json:"square_pixels" yaml:"square_pixels"`         // Target displays assume a 1:1 sample aspect ratio
+	Stereo3D        bool   `json:"stereo_3d" yaml:"stereo_3d"`                 // Target players render stereoscopic 3D
+
+	// Delivery spec limits, usually set in a profile file
+	VideoCodecs        []string  `json:"video_codecs,omitempty" yaml:"video_codecs,omitempty"`                 // Allowed ffprobe codec names, e.g. h264
+	MaxWidth           int       `json:"max_width,omitempty" yaml:"max_width,omitempty"`                       // Pixels
+	MaxHeight          int       `json:"max_height,omitempty" yaml:"max_height,omitempty"`                     // Pixels
+	MaxVideoBitrate    int64     `json:"max_video_bitrate,omitempty" yaml:"max_video_bitrate,omitempty"`       // Bits/s
+	TargetVideoBitrate int64     `json:"target_video_bitrate,omitempty" yaml:"target_video_bitrate,omitempty"` // Bits/s
+	BitrateTolerance   float64   `json:"bitrate_tolerance,omitempty" yaml:"bitrate_tolerance,omitempty"`       // Percent around TargetVideoBitrate; 0 uses 20
+	FrameRates         []float64 `json:"frame_rates,omitempty" yaml:"frame_rates,omitempty"`                   // Allowed frame rates, e.g. 25, 29.97
+	MaxFrameRate       float64   `json:"max_frame_rate,omitempty" yaml:"max_frame_rate,omitempty"`
+	AudioCodecs        []string  `json:"audio_codecs,omitempty" yaml:"audio_codecs,omitempty"` // Allowed ffprobe codec names, e.g. aac
+	AudioChannels      int       `json:"audio_channels,omitempty" yaml:"audio_channels,omitempty"`
+}
+
+// builtinProfiles are the profiles selectable by name with --profile
+var builtinProfiles = map[string]Profile{
+	"vod": {
+		Name:            "vod",
+		MinVideoStreams: 1,
+		MaxVideoStreams: 1,
+		MinAudioStreams: 1,
+	},
+	"broadcast": {
+		Name:            "broadcast",
+		MinVideoStreams: 1,
+		MaxVideoStreams: 1,
+		MinAudioStreams: 1,
+		AudioSampleRate: 48000,
+	},
+	"web": {
+		Name:            "web",
+		MinVideoStreams: 1,
+		MaxVideoStreams: 1,
+		MinAudioStreams: 1,
+		MaxAudioStreams: 1,
+		SquarePixels:    true,
+	},
+	"mobile": {
+		Name:            "mobile",
+		MinVideoStreams: 1,
+		MaxVideoStreams: 1,
+		MinAudioStreams: 1,
+		MaxAudioStreams: 1,
+		SquarePixels:    true,
+	},
+}
+
+// LookupProfile returns the built-in profile with the given name
+func LookupProfile(name string) (*Profile, error) {
+	profile, ok := builtinProfiles[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile %q (available: %s)", name, strings.Join(ProfileNames(), ", "))
+	}
+	return &profile, nil
+}
+
+// LoadProfile reads a profile file. Files ending in .yaml or .yml are parsed
+// as YAML, anything else as JSON. A profile without a name is named after
+// the file.
+func LoadProfile(path string) (*Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profile: %w", err)
+	}
+
+	var profile Profile
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&profile)
+	default:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&profile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse profile %s: %w", path, err)
+	}
+
+	if err := profile.validate(); err != nil {
+		return nil, fmt.Errorf("invalid profile %s: %w", path, err)
+	}
+	if profile.Name == "" {
+		profile.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return &profile, nil
+}
+
+func (p *Profile) validate() error {
+	switch {
+	case p.MinVideoStreams < 0 || p.MaxVideoStreams < 0 || p.MinAudioStreams < 0 || p.MaxAudioStreams < 0:
+		return fmt.Errorf("stream counts must not be negative")
+	case p.MaxVideoStreams > 0 && p.MinVideoStreams > p.MaxVideoStreams:
+		return fmt.Errorf("min_video_streams %d exceeds max_video_streams %d", p.MinVideoStreams, p.MaxVideoStreams)
+	case p.MaxAudioStreams > 0 && p.MinAudioStreams > p.MaxAudioStreams:
+		return fmt.Errorf("min_audio_streams %d exceeds max_audio_streams %d", p.MinAudioStreams, p.MaxAudioStreams)
+	case p.MaxWidth < 0 || p.MaxHeight < 0:
+		return fmt.Errorf("max_width and max_height must not be negative")
+	case p.MaxVideoBitrate < 0 || p.TargetVideoBitrate < 0 || p.BitrateTolerance < 0:
+		return fmt.Errorf("bitrates and bitrate_tolerance must not be negative")
+	case p.MaxVideoBitrate > 0 && p.TargetVideoBitrate > p.MaxVideoBitrate:
+		return fmt.Errorf("target_video_bitrate %d exceeds max_video_bitrate %d", p.TargetVideoBitrate, p.MaxVideoBitrate)
+	case p.MaxFrameRate < 0 || p.AudioChannels < 0 || p.AudioSampleRate < 0:
+		return fmt.Errorf("max_frame_rate, audio_channels and audio_sample_rate must not be negative")
+	}
+	for _, rate := range p.FrameRates {
+		if rate <= 0 {
+			return fmt.Errorf("frame_rates must be positive, got %g", rate)
+		}
+	}
+	return nil
+}
+
+// ProfileNames lists the built-in profile names in sorted order
+func ProfileNames() []string {
+	names := make([]string, 0, len(builtinProfiles))
+	for name := range builtinProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DetectStreamLayout checks the number of video and audio streams against
+// the profile's expectations. streamCounts maps a codec type ("video",
+// "audio", ...) to the number of streams of that type.
+func (d *Detector) DetectStreamLayout(profile *Profile, streamCounts map[string]int) {
+	if profile == nil {
+		return
+	}
+
+	video := streamCounts["video"]
+	audio := streamCounts["audio"]
+
+	violations := make([]string, 0)
+	if video < profile.MinVideoStreams {
+		violations = append(violations, fmt.Sprintf("expected at least %d video stream(s)", profile.MinVideoStreams))
+	}
+	if profile.MaxVideoStreams > 0 && video > profile.MaxVideoStreams {
+		violations = append(violations, fmt.Sprintf("expected at most %d video stream(s)", profile.MaxVideoStreams))
+	}
+	if audio < profile.MinAudioStreams {
+		violations = append(violations, fmt.Sprintf("expected at least %d audio stream(s)", profile.MinAudioStreams))
+	}
+	if profile.MaxAudioStreams > 0 && audio > profile.MaxAudioStreams {
+		violations = append(violations, fmt.Sprintf("expected at most %d audio stream(s)", profile.MaxAudioStreams))
+	}
+
+	if len(violations) == 0 {
+		return
+	}
+
+	d.addProblem(Problem{
+		Severity:   SeverityWarning,
+		Category:   CategoryCompatibility,
+		Code:       "UNEXPECTED_STREAM_LAYOUT",
+		Message:    fmt.Sprintf("Stream layout does not match the %s profile", profile.Name),
+		Details:    fmt.Sprintf("Actual: %d video, %d audio; %s", video, audio, strings.Join(violations, ", ")),
+		Suggestion: "Add or remove streams so the file matches the deliverable spec",
+	})
+}
+
+// DetectAudioSampleRate checks an audio stream's sample rate against the rate
+// the profile requires, e.g. 48 kHz for broadcast deliverables
+func (d *Detector) DetectAudioSampleRate(profile *Profile, sampleRate, streamIndex int) {
+	if profile == nil || profile.AudioSampleRate == 0 || sampleRate == profile.AudioSampleRate {
+		return
+	}
+
+	d.addProblem(Problem{
+		Severity:    SeverityError,
+		Category:    CategoryAudio,
+		Code:        "AUDIO_SAMPLE_RATE_NONCOMPLIANT",
+		Message:     fmt.Sprintf("Audio sample rate does not match the %s profile", profile.Name),
+		Details:     fmt.Sprintf("Actual: %d Hz, required: %d Hz", sampleRate, profile.AudioSampleRate),
+		Suggestion:  fmt.Sprintf("Resample the audio to %d Hz (e.g. ffmpeg -ar %d)", profile.AudioSampleRate, profile.AudioSampleRate),
+		StreamIndex: streamIndex,
+	})
+}
@@ -0,0 +1,70 @@
+package detector
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Rendition is one variant of an adaptive (HLS/DASH) stream
+type Rendition struct {
+	Name       string // Display name, e.g. "program 2 (2.5 Mbps)"
+	VideoCodec string
+	AudioCodec string
+}
+
+// IsAdaptiveFormat reports whether an ffprobe format_name is an adaptive
+// streaming manifest whose programs are renditions
+func IsAdaptiveFormat(formatName string) bool {
+	for _, name := range strings.Split(strings.ToLower(formatName), ",") {
+		if name == "hls" || name == "dash" {
+			return true
+		}
+	}
+	return false
+}
+
+// DetectRenditionCodecs flags adaptive streams whose renditions use different
+// video or audio codecs. Players switch renditions without reinitializing the
+// decoder, so a mixed ladder stalls or fails on switches. Renditions without
+// a stream of a type (e.g. audio-only variants) are not compared for it.
+func (d *Detector) DetectRenditionCodecs(renditions []Rendition) {
+	if len(renditions) < 2 {
+		return
+	}
+
+	for _, kind := range []string{"video", "audio"} {
+		byCodec := make(map[string][]string)
+		for _, r := range renditions {
+			codec := r.VideoCodec
+			if kind == "audio" {
+				codec = r.AudioCodec
+			}
+			if codec != "" {
+				byCodec[codec] = append(byCodec[codec], r.Name)
+			}
+		}
+		if len(byCodec) < 2 {
+			continue
+		}
+
+		codecs := make([]string, 0, len(byCodec))
+		for codec := range byCodec {
+			codecs = append(codecs, codec)
+		}
+		sort.Strings(codecs)
+		groups := make([]string, len(codecs))
+		for i, codec := range codecs {
+			groups[i] = fmt.Sprintf("%s: %s", codec, strings.Join(byCodec[codec], ", "))
+		}
+
+		d.addProblem(Problem{
+			Severity:   SeverityWarning,
+			Category:   CategoryCompatibility,
+			Code:       "CODEC_INCONSISTENT_ACROSS_RENDITIONS",
+			Message:    fmt.Sprintf("Renditions use different %s codecs (%s)", kind, strings.Join(codecs, ", ")),
+			Details:    strings.Join(groups, "; "),
+			Suggestion: fmt.Sprintf("Encode every rendition with the same %s codec, or publish each codec as a separate ladder", kind),
+		})
+	}
+}
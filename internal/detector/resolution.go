@@ -0,0 +1,120 @@
+package detector
+
+import (
+	"fmt"
+	"strings"
+)
+
+// standardResolution is a common delivery resolution
+type standardResolution struct {
+	width, height int
+	name          string
+}
+
+// standardResolutions are the landscape delivery sizes checked by
+// DetectNonstandardResolution; portrait variants are matched by swapping
+var standardResolutions = []standardResolution{
+	{426, 240, "240p"},
+	{640, 360, "360p"},
+	{640, 480, "VGA"},
+	{704, 480, "NTSC SD"},
+	{704, 576, "PAL SD"},
+	{720, 480, "NTSC SD"},
+	{720, 576, "PAL SD"},
+	{854, 480, "480p"},
+	{960, 540, "540p"},
+	{1024, 576, "576p"},
+	{1280, 720, "720p"},
+	{1440, 1080, "1080 anamorphic"},
+	{1600, 900, "900p"},
+	{1920, 1080, "1080p"},
+	{2048, 1080, "DCI 2K"},
+	{2560, 1440, "1440p"},
+	{3840, 2160, "UHD 4K"},
+	{4096, 2160, "DCI 4K"},
+	{7680, 4320, "UHD 8K"},
+}
+
+// nonstandardTolerance is how far, in pixels per dimension, a size may be
+// from a standard resolution to be considered a near miss
+const nonstandardTolerance = 16
+
+func absInt(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// DetectNonstandardResolution flags dimensions that are close to, but not
+// exactly, a standard resolution (e.g. 1920x1090), which usually points to
+// a crop or scale mistake
+func (d *Detector) DetectNonstandardResolution(width, height, streamIndex int) {
+	if width <= 0 || height <= 0 {
+		return
+	}
+
+	var nearest *standardResolution
+	nearestW, nearestH := 0, 0
+	bestDistance := 0
+	for i := range standardResolutions {
+		std := &standardResolutions[i]
+		for _, size := range [][2]int{{std.width, std.height}, {std.height, std.width}} {
+			dw, dh := absInt(width-size[0]), absInt(height-size[1])
+			if dw == 0 && dh == 0 {
+				return
+			}
+			if dw > nonstandardTolerance || dh > nonstandardTolerance {
+				continue
+			}
+			if nearest == nil || dw+dh < bestDistance {
+				nearest, nearestW, nearestH, bestDistance = std, size[0], size[1], dw+dh
+			}
+		}
+	}
+	if nearest == nil {
+		return
+	}
+
+	d.addProblem(Problem{
+		Severity:    SeverityWarning,
+		Category:    CategoryResolution,
+		Code:        "NONSTANDARD_RESOLUTION",
+		Message:     fmt.Sprintf("Resolution %dx%d is close to but not exactly %dx%d (%s)", width, height, nearestW, nearestH, nearest.name),
+		Details:     fmt.Sprintf("Actual: %dx%d, nearest standard: %dx%d", width, height, nearestW, nearestH),
+		Suggestion:  fmt.Sprintf("Check the crop/scale settings; the intended size was probably %dx%d", nearestW, nearestH),
+		StreamIndex: streamIndex,
+	})
+}
+
+// DetectResolutionChanges walks the video frames of each stream and raises an
+// error for every frame whose dimensions differ from the previous frame's, as
+// happens in adaptive captures that switch renditions mid-stream. Frames
+// without dimensions are skipped.
+func (d *Detector) DetectResolutionChanges(frames []FrameInfo) {
+	type size struct{ width, height int }
+	previous := make(map[int]size)
+
+	for _, frame := range frames {
+		if strings.ToLower(frame.MediaType) != "video" || frame.AttachedPic || frame.Width <= 0 || frame.Height <= 0 {
+			continue
+		}
+		current := size{frame.Width, frame.Height}
+		prev, seen := previous[frame.StreamIndex]
+		previous[frame.StreamIndex] = current
+		if !seen || prev == current {
+			continue
+		}
+
+		d.addProblem(Problem{
+			Severity:    SeverityError,
+			Category:    CategoryResolution,
+			Code:        "RESOLUTION_CHANGE",
+			Message:     fmt.Sprintf("Resolution changes mid-stream at %.3fs", frame.PTS),
+			Details:     fmt.Sprintf("%dx%d -> %dx%d", prev.width, prev.height, current.width, current.height),
+			Timestamp:   frame.PTS,
+			StreamIndex: frame.StreamIndex,
+			Suggestion:  "Scale the video to a single resolution (e.g. ffmpeg -vf scale=W:H) before processing",
+		})
+	}
+}
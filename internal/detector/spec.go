@@ -0,0 +1,193 @@
+package detector
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// defaultBitrateTolerance is the percentage a video bitrate may stray from
+// a profile's target bitrate
+const defaultBitrateTolerance = 20.0
+
+// frameRateTolerance absorbs the difference between a rounded spec rate
+// (29.97) and the exact one (30000/1001)
+const frameRateTolerance = 0.01
+
+// profileProblemCodes are the problems raised for violations of a profile.
+// The validate command judges a file by these alone.
+var profileProblemCodes = map[string]bool{
+	"UNEXPECTED_STREAM_LAYOUT":       true,
+	"AUDIO_SAMPLE_RATE_NONCOMPLIANT": true,
+	"ANAMORPHIC_FOR_SQUARE_DISPLAY":  true,
+	"STEREO_3D_FOR_2D_DELIVERY":      true,
+	"VIDEO_CODEC_NOT_ALLOWED":        true,
+	"RESOLUTION_EXCEEDS_PROFILE":     true,
+	"VIDEO_BITRATE_EXCEEDS_PROFILE":  true,
+	"VIDEO_BITRATE_OFF_TARGET":       true,
+	"FRAME_RATE_NOT_ALLOWED":         true,
+	"AUDIO_CODEC_NOT_ALLOWED":        true,
+	"AUDIO_CHANNELS_NONCOMPLIANT":    true,
+}
+
+// IsProfileProblem reports whether code is raised by a profile check
+func IsProfileProblem(code string) bool {
+	return profileProblemCodes[code]
+}
+
+// containsFold reports whether list holds s, ignoring case
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// DetectVideoSpec checks the video stream's codec, resolution, bitrate and
+// frame rate against the profile's delivery spec. Fields the profile leaves
+// at zero, and stream values ffprobe did not report, are not checked.
+func (d *Detector) DetectVideoSpec(profile *Profile, params VideoParams) {
+	if profile == nil {
+		return
+	}
+
+	if len(profile.VideoCodecs) > 0 && !containsFold(profile.VideoCodecs, params.Codec) {
+		d.addProblem(Problem{
+			Severity:    SeverityError,
+			Category:    CategoryCompatibility,
+			Code:        "VIDEO_CODEC_NOT_ALLOWED",
+			Message:     fmt.Sprintf("Video codec %s is not allowed by the %s profile", params.Codec, profile.Name),
+			Details:     fmt.Sprintf("Actual: %s, allowed: %s", params.Codec, strings.Join(profile.VideoCodecs, ", ")),
+			Suggestion:  fmt.Sprintf("Re-encode the video as %s", profile.VideoCodecs[0]),
+			StreamIndex: params.Index,
+		})
+	}
+
+	if params.Width > 0 && params.Height > 0 &&
+		(profile.MaxWidth > 0 && params.Width > profile.MaxWidth || profile.MaxHeight > 0 && params.Height > profile.MaxHeight) {
+		d.addProblem(Problem{
+			Severity:    SeverityError,
+			Category:    CategoryCompatibility,
+			Code:        "RESOLUTION_EXCEEDS_PROFILE",
+			Message:     fmt.Sprintf("Resolution %dx%d exceeds the %s profile", params.Width, params.Height, profile.Name),
+			Details:     fmt.Sprintf("Actual: %dx%d, maximum: %s", params.Width, params.Height, maxSize(profile.MaxWidth, profile.MaxHeight)),
+			Suggestion:  "Scale the video down to fit the profile's maximum resolution",
+			StreamIndex: params.Index,
+		})
+	}
+
+	if params.Bitrate > 0 && profile.MaxVideoBitrate > 0 && params.Bitrate > profile.MaxVideoBitrate {
+		d.addProblem(Problem{
+			Severity:    SeverityError,
+			Category:    CategoryCompatibility,
+			Code:        "VIDEO_BITRATE_EXCEEDS_PROFILE",
+			Message:     fmt.Sprintf("Video bitrate exceeds the %s profile", profile.Name),
+			Details:     fmt.Sprintf("Actual: %.2f Mbps, maximum: %.2f Mbps", float64(params.Bitrate)/1000000, float64(profile.MaxVideoBitrate)/1000000),
+			Suggestion:  fmt.Sprintf("Re-encode with a bitrate cap, e.g. ffmpeg -maxrate %d -bufsize %d", profile.MaxVideoBitrate, 2*profile.MaxVideoBitrate),
+			StreamIndex: params.Index,
+		})
+	}
+
+	if params.Bitrate > 0 && profile.TargetVideoBitrate > 0 {
+		tolerance := profile.BitrateTolerance
+		if tolerance <= 0 {
+			tolerance = defaultBitrateTolerance
+		}
+		target := float64(profile.TargetVideoBitrate)
+		deviation := (float64(params.Bitrate) - target) / target * 100
+		if math.Abs(deviation) > tolerance {
+			d.addProblem(Problem{
+				Severity:    SeverityWarning,
+				Category:    CategoryCompatibility,
+				Code:        "VIDEO_BITRATE_OFF_TARGET",
+				Message:     fmt.Sprintf("Video bitrate is %.0f%% off the %s profile's target", deviation, profile.Name),
+				Details:     fmt.Sprintf("Actual: %.2f Mbps, target: %.2f Mbps ±%g%%", float64(params.Bitrate)/1000000, target/1000000, tolerance),
+				Suggestion:  fmt.Sprintf("Re-encode at the target bitrate, e.g. ffmpeg -b:v %d", profile.TargetVideoBitrate),
+				StreamIndex: params.Index,
+			})
+		}
+	}
+
+	if params.FPS > 0 && !frameRateAllowed(profile, params.FPS) {
+		allowed := make([]string, 0, len(profile.FrameRates)+1)
+		for _, rate := range profile.FrameRates {
+			allowed = append(allowed, fmt.Sprintf("%g", rate))
+		}
+		if profile.MaxFrameRate > 0 {
+			allowed = append(allowed, fmt.Sprintf("at most %g", profile.MaxFrameRate))
+		}
+		d.addProblem(Problem{
+			Severity:    SeverityError,
+			Category:    CategoryCompatibility,
+			Code:        "FRAME_RATE_NOT_ALLOWED",
+			Message:     fmt.Sprintf("Frame rate %.3f fps is not allowed by the %s profile", params.FPS, profile.Name),
+			Details:     fmt.Sprintf("Actual: %s (%.3f fps), allowed: %s", params.FrameRate, params.FPS, strings.Join(allowed, ", ")),
+			Suggestion:  "Convert the frame rate to one the profile allows (e.g. ffmpeg -r)",
+			StreamIndex: params.Index,
+		})
+	}
+}
+
+// frameRateAllowed reports whether fps satisfies the profile's frame rate
+// list and maximum
+func frameRateAllowed(profile *Profile, fps float64) bool {
+	if profile.MaxFrameRate > 0 && fps > profile.MaxFrameRate+frameRateTolerance {
+		return false
+	}
+	if len(profile.FrameRates) == 0 {
+		return true
+	}
+	for _, rate := range profile.FrameRates {
+		if math.Abs(fps-rate) <= frameRateTolerance {
+			return true
+		}
+	}
+	return false
+}
+
+// maxSize formats a maximum resolution where either dimension may be
+// unlimited
+func maxSize(width, height int) string {
+	switch {
+	case width > 0 && height > 0:
+		return fmt.Sprintf("%dx%d", width, height)
+	case width > 0:
+		return fmt.Sprintf("%d wide", width)
+	default:
+		return fmt.Sprintf("%d high", height)
+	}
+}
+
+// DetectAudioSpec checks an audio stream's codec and channel count against
+// the profile's delivery spec
+func (d *Detector) DetectAudioSpec(profile *Profile, codec string, channels, streamIndex int) {
+	if profile == nil {
+		return
+	}
+
+	if len(profile.AudioCodecs) > 0 && !containsFold(profile.AudioCodecs, codec) {
+		d.addProblem(Problem{
+			Severity:    SeverityError,
+			Category:    CategoryCompatibility,
+			Code:        "AUDIO_CODEC_NOT_ALLOWED",
+			Message:     fmt.Sprintf("Audio codec %s is not allowed by the %s profile", codec, profile.Name),
+			Details:     fmt.Sprintf("Actual: %s, allowed: %s", codec, strings.Join(profile.AudioCodecs, ", ")),
+			Suggestion:  fmt.Sprintf("Re-encode the audio as %s", profile.AudioCodecs[0]),
+			StreamIndex: streamIndex,
+		})
+	}
+
+	if profile.AudioChannels > 0 && channels > 0 && channels != profile.AudioChannels {
+		d.addProblem(Problem{
+			Severity:    SeverityError,
+			Category:    CategoryCompatibility,
+			Code:        "AUDIO_CHANNELS_NONCOMPLIANT",
+			Message:     fmt.Sprintf("Audio has %d channel(s), the %s profile requires %d", channels, profile.Name, profile.AudioChannels),
+			Details:     fmt.Sprintf("Actual: %d, required: %d", channels, profile.AudioChannels),
+			Suggestion:  fmt.Sprintf("Remix the audio to %d channel(s) (e.g. ffmpeg -ac %d)", profile.AudioChannels, profile.AudioChannels),
+			StreamIndex: streamIndex,
+		})
+	}
+}
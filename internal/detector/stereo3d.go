@@ -0,0 +1,36 @@
+package detector
+
+import "fmt"
+
+// DetectStereo3D notes video carrying stereoscopic 3D side data, which
+// players must support to show the intended picture. When the selected
+// profile targets 2D playback, the two views would be shown packed into a
+// single frame, so that is raised as a warning.
+func (d *Detector) DetectStereo3D(layout string, profile *Profile, streamIndex int) {
+	if layout == "" {
+		return
+	}
+
+	d.addProblem(Problem{
+		Severity:    SeverityInfo,
+		Category:    CategoryCompatibility,
+		Code:        "STEREOSCOPIC_3D_CONTENT",
+		Message:     fmt.Sprintf("Video is stereoscopic 3D (%s)", layout),
+		Details:     fmt.Sprintf("Layout: %s; players without 3D support show both views in one frame", layout),
+		Suggestion:  "Make sure the target players support stereoscopic 3D",
+		StreamIndex: streamIndex,
+	})
+
+	if profile == nil || profile.Stereo3D {
+		return
+	}
+	d.addProblem(Problem{
+		Severity:    SeverityWarning,
+		Category:    CategoryCompatibility,
+		Code:        "STEREO_3D_FOR_2D_DELIVERY",
+		Message:     fmt.Sprintf("Stereoscopic 3D video does not suit the %s profile's 2D delivery", profile.Name),
+		Details:     fmt.Sprintf("Layout: %s, profile: %s", layout, profile.Name),
+		Suggestion:  "Deliver a 2D version, e.g. extract one view with ffmpeg -vf stereo3d=sbsl:ml (adjust the input layout)",
+		StreamIndex: streamIndex,
+	})
+}
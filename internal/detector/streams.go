@@ -0,0 +1,61 @@
+package detector
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DetectCoverArt reports when a file's only video-typed stream is an
+// attached picture (album art), so it is not mistaken for a video file
+func (d *Detector) DetectCoverArt(codec string, width, height, streamIndex int, hasVideo bool) {
+	if hasVideo {
+		return
+	}
+
+	d.addProblem(Problem{
+		Severity:    SeverityInfo,
+		Category:    CategoryContainer,
+		Code:        "VIDEO_IS_COVER_ART",
+		Message:     "The only video stream is cover art, not video",
+		Details:     fmt.Sprintf("Attached picture: %s %dx%d", codec, width, height),
+		Suggestion:  "Treat this file as audio; the picture is embedded album art",
+		StreamIndex: streamIndex,
+	})
+}
+
+// DataStreamType returns a best-effort label for a data stream from its
+// codec name and codec tag: SCTE-35, KLV or timed_id3, falling back to the
+// codec name (or "unknown")
+func DataStreamType(codec, tag string) string {
+	codec = strings.ToLower(codec)
+	switch {
+	case codec == "scte_35" || strings.EqualFold(tag, "CUEI"):
+		return "SCTE-35"
+	case codec == "klv" || strings.EqualFold(tag, "KLVA"):
+		return "KLV"
+	case codec == "timed_id3" || strings.EqualFold(strings.TrimSpace(tag), "ID3"):
+		return "timed_id3"
+	case codec != "":
+		return codec
+	default:
+		return "unknown"
+	}
+}
+
+// DetectDataStream notes a data stream, e.g. SCTE-35 splice info used for ad
+// insertion or KLV metadata, which players ignore but workflows may rely on
+func (d *Detector) DetectDataStream(streamType, codec string, streamIndex int) {
+	details := fmt.Sprintf("Type: %s", streamType)
+	if codec != "" && !strings.EqualFold(codec, streamType) {
+		details += fmt.Sprintf(" (codec %s)", codec)
+	}
+	d.addProblem(Problem{
+		Severity:    SeverityInfo,
+		Category:    CategoryContainer,
+		Code:        "DATA_STREAM_PRESENT",
+		Message:     fmt.Sprintf("%s data stream present", streamType),
+		Details:     details,
+		Suggestion:  "Make sure transcoding and packaging steps pass this stream through if downstream systems need it",
+		StreamIndex: streamIndex,
+	})
+}
@@ -0,0 +1,77 @@
+package detector
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// fragmentedBrands are ISO BMFF brands signalling fragmented MP4 (DASH, CMAF)
+var fragmentedBrands = []string{"iso5", "iso6", "iso8", "iso9", "dash", "msdh", "msix", "cmfc", "cmf2"}
+
+// commonTimescales are movie/media timescales that players handle reliably
+var commonTimescales = map[int]bool{
+	600:   true,
+	1000:  true,
+	12800: true,
+	15360: true,
+	24000: true,
+	25000: true,
+	30000: true,
+	50000: true,
+	60000: true,
+	90000: true,
+}
+
+// IsFragmentedMP4 reports whether an ISO BMFF file declares a fragmented
+// (DASH/CMAF) brand in its major or compatible brands
+func IsFragmentedMP4(formatName string, tags map[string]string) bool {
+	if !strings.Contains(formatName, "mp4") && !strings.Contains(formatName, "mov") {
+		return false
+	}
+
+	brands := strings.ToLower(tags["major_brand"] + tags["compatible_brands"])
+	for _, brand := range fragmentedBrands {
+		if strings.Contains(brands, brand) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTimescale returns the timescale (ticks per second) of an ffprobe
+// time_base such as "1/90000", or 0 when it cannot be interpreted
+func ParseTimescale(timeBase string) int {
+	num, den, ok := strings.Cut(timeBase, "/")
+	if !ok || strings.TrimSpace(num) != "1" {
+		return 0
+	}
+	timescale, err := strconv.Atoi(strings.TrimSpace(den))
+	if err != nil || timescale <= 0 {
+		return 0
+	}
+	return timescale
+}
+
+// DetectUnusualTimescale flags fragmented MP4 streams whose media timescale
+// deviates from the usual values. Audio timescales equal to the sample rate
+// are always considered normal; pass sampleRate 0 for video streams.
+func (d *Detector) DetectUnusualTimescale(timeBase string, sampleRate, streamIndex int) {
+	timescale := ParseTimescale(timeBase)
+	if timescale == 0 || commonTimescales[timescale] {
+		return
+	}
+	if sampleRate > 0 && timescale == sampleRate {
+		return
+	}
+
+	d.addProblem(Problem{
+		Severity:    SeverityInfo,
+		Category:    CategoryContainer,
+		Code:        "UNUSUAL_TIMESCALE",
+		Message:     fmt.Sprintf("Fragmented MP4 stream uses an unusual timescale of %d", timescale),
+		Details:     fmt.Sprintf("Time base: %s", timeBase),
+		Suggestion:  "Some players mishandle uncommon timescales; consider packaging with 90000 for video or the sample rate for audio",
+		StreamIndex: streamIndex,
+	})
+}
@@ -0,0 +1,98 @@
+package fixer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/tomi/media-parser-cli/internal/analyzer"
+)
+
+// Plan is a lossless ffmpeg remux that addresses detected problems
+type Plan struct {
+	Binary  string
+	Args    []string
+	Reasons []string
+}
+
+// mp4Codecs lists codecs that can be stream-copied into MP4/MOV
+var mp4Codecs = map[string]bool{
+	"h264": true, "hevc": true, "av1": true, "mpeg4": true, "vp9": true,
+	"aac": true, "mp3": true, "ac3": true, "eac3": true, "alac": true, "opus": true, "flac": true,
+	"mov_text": true,
+}
+
+// NewPlan builds the ffmpeg invocation that remuxes input into output
+// without re-encoding. It returns an error when the problems found can only
+// be fixed by re-encoding.
+func NewPlan(result *analyzer.DetailedAnalysis, output string) (*Plan, error) {
+	info := result.MediaInfo
+	ext := strings.ToLower(filepath.Ext(output))
+	if ext == "" {
+		return nil, fmt.Errorf("output %q has no file extension to select a container", output)
+	}
+	toMP4 := ext == ".mp4" || ext == ".m4v" || ext == ".m4a" || ext == ".mov"
+
+	plan := &Plan{
+		Binary: "ffmpeg",
+		Args:   []string{"-hide_banner", "-i", info.Input, "-map", "0", "-c", "copy", "-map_metadata", "0"},
+	}
+
+	if toMP4 {
+		for _, stream := range info.Streams {
+			if mp4Codecs[stream.Codec] {
+				continue
+			}
+			switch stream.CodecType {
+			case "subtitle", "data", "attachment":
+				plan.Args = append(plan.Args, "-map", fmt.Sprintf("-0:%d", stream.Index))
+				plan.Reasons = append(plan.Reasons, fmt.Sprintf("drop %s stream %d (%s) that MP4 cannot carry", stream.CodecType, stream.Index, stream.Codec))
+			default:
+				return nil, fmt.Errorf("stream %d (%s) cannot be stored in %s without re-encoding", stream.Index, stream.Codec, ext)
+			}
+		}
+		plan.Args = append(plan.Args, "-movflags", "+faststart")
+		plan.Reasons = append(plan.Reasons, "move the moov atom to the front for progressive playback (+faststart)")
+	}
+
+	codes := make(map[string]bool)
+	for _, p := range result.Problems {
+		codes[p.Code] = true
+	}
+	if codes["NEGATIVE_FIRST_PTS"] {
+		plan.Args = append(plan.Args, "-avoid_negative_ts", "make_zero")
+		plan.Reasons = append(plan.Reasons, "shift timestamps so the first PTS is zero")
+	}
+	if codes["CONTAINER_COMPATIBILITY"] && toMP4 {
+		plan.Reasons = append(plan.Reasons, "remux into a web-compatible MP4 container")
+	}
+
+	plan.Args = append(plan.Args, output)
+	return plan, nil
+}
+
+// String renders the plan as a shell command line
+func (p *Plan) String() string {
+	parts := []string{p.Binary}
+	for _, arg := range p.Args {
+		if arg == "" || strings.ContainsAny(arg, " \t'\"$&;|<>()*?") {
+			arg = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+		parts = append(parts, arg)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Run executes the plan, streaming ffmpeg's output to stderr
+func (p *Plan) Run(ctx context.Context) error {
+	cmd := exec.CommandContext(ctx, p.Binary, p.Args...)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ffmpeg failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package reporter
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/tomi/media-parser-cli/internal/analyzer"
+)
+
+// BatchResult is the outcome of analyzing one input of a batch
+type BatchResult struct {
+	Input   string            `json:"input"`
+	Summary *analyzer.Summary `json:"summary,omitempty"`
+	Error   string            `json:"error,omitempty"` // Why the input could not be analyzed
+}
+
+// BatchReport combines the results of a batch run
+type BatchReport struct {
+	Files         int            `json:"files"`
+	Analyzed      int            `json:"analyzed"`
+	Failed        int            `json:"failed"`
+	ProblemCounts map[string]int `json:"problem_counts"` // Per severity, over all analyzed files
+	Results       []BatchResult  `json:"results"`
+}
+
+// NewBatchReport totals results into a report, keeping their order
+func NewBatchReport(results []BatchResult) *BatchReport {
+	report := &BatchReport{
+		Files: len(results),
+		ProblemCounts: map[string]int{
+			"critical": 0,
+			"error":    0,
+			"warning":  0,
+			"info":     0,
+		},
+		Results: results,
+	}
+	for _, result := range results {
+		if result.Summary == nil {
+			report.Failed++
+			continue
+		}
+		report.Analyzed++
+		for severity, count := range result.Summary.ProblemCounts {
+			report.ProblemCounts[severity] += count
+		}
+	}
+	return report
+}
+
+// PrintBatch prints a batch report in the configured format
+func (r *Reporter) PrintBatch(report *BatchReport) error {
+	switch r.options.Format {
+	case FormatJSON:
+		encoder := json.NewEncoder(r.writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case FormatYAML:
+		return r.encodeYAML(report)
+	default:
+		return r.printBatchText(report)
+	}
+}
+
+// batchCSVHeader is the header row of PrintBatchCSV
+var batchCSVHeader = []string{"input", "status", "format", "duration", "video_codec", "width", "height", "audio_codec", "critical", "error", "warning", "info", "failure"}
+
+// PrintBatchCSV prints one CSV row per input with its problem counts
+func (r *Reporter) PrintBatchCSV(report *BatchReport) error {
+	writer := csv.NewWriter(r.writer)
+	writer.Write(batchCSVHeader)
+	for _, result := range report.Results {
+		summary := result.Summary
+		if summary == nil {
+			writer.Write([]string{result.Input, "FAIL", "", "", "", "", "", "", "", "", "", "", result.Error})
+			continue
+		}
+		writer.Write([]string{
+			result.Input,
+			onelineStatus(summary),
+			summary.Format,
+			strconv.FormatFloat(summary.Duration, 'f', -1, 64),
+			summary.VideoCodec,
+			strconv.Itoa(summary.Width),
+			strconv.Itoa(summary.Height),
+			summary.AudioCodec,
+			strconv.Itoa(summary.ProblemCounts["critical"]),
+			strconv.Itoa(summary.ProblemCounts["error"]),
+			strconv.Itoa(summary.ProblemCounts["warning"]),
+			strconv.Itoa(summary.ProblemCounts["info"]),
+			"",
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func (r *Reporter) printBatchText(report *BatchReport) error {
+	w := tabwriter.NewWriter(r.writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Status\tInput\tVideo\tCritical\tErrors\tWarnings\tInfo\n")
+	fmt.Fprintf(w, "------\t-----\t-----\t--------\t------\t--------\t----\n")
+	for _, result := range report.Results {
+		summary := result.Summary
+		if summary == nil {
+			fmt.Fprintf(w, "FAIL\t%s\t-\t-\t-\t-\t-\n", result.Input)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%d\t%d\n",
+			onelineStatus(summary), result.Input, onelineVideo(summary),
+			summary.ProblemCounts["critical"], summary.ProblemCounts["error"],
+			summary.ProblemCounts["warning"], summary.ProblemCounts["info"])
+	}
+	w.Flush()
+
+	if report.Failed > 0 {
+		fmt.Fprintln(r.writer, "\nFAILED TO ANALYZE:")
+		for _, result := range report.Results {
+			if result.Summary == nil {
+				fmt.Fprintf(r.writer, "  %s: %s\n", result.Input, result.Error)
+			}
+		}
+	}
+
+	fmt.Fprintln(r.writer, "\n"+strings.Repeat("-", 40))
+	fmt.Fprintf(r.writer, "Files: %d analyzed, %d failed\n", report.Analyzed, report.Failed)
+	fmt.Fprintf(r.writer, "Problems: %d critical, %d errors, %d warnings, %d info\n",
+		report.ProblemCounts["critical"], report.ProblemCounts["error"],
+		report.ProblemCounts["warning"], report.ProblemCounts["info"])
+	return nil
+}
@@ -0,0 +1,87 @@
+package reporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// canonicalExcluded are the JSON keys left out of canonical output, at any
+// depth: values that change between runs or machines for the same input
+// (analysis time, absolute path, diagnostics with timings and cache state,
+// ffprobe's log, which varies with the ffmpeg version) and the raw packet
+// and frame lists.
+var canonicalExcluded = map[string]bool{
+	"analyzed_at":   true,
+	"absolute_path": true,
+	"diagnostics":   true,
+	"ffprobe_log":   true,
+	"packets":       true,
+	"frames":        true,
+}
+
+// PrintCanonical writes v as sorted key=value lines, one per leaf of its
+// JSON form, so snapshots can be committed and diffed. Keys are dotted JSON
+// paths with zero-padded array indexes (video_streams.0000.codec); strings
+// are quoted.
+func (r *Reporter) PrintCanonical(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber() // Keep numbers exactly as encoded
+	var tree interface{}
+	if err := decoder.Decode(&tree); err != nil {
+		return err
+	}
+
+	lines := make([]string, 0)
+	flattenCanonical("", tree, &lines)
+	sort.Strings(lines)
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(r.writer, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flattenCanonical appends a key=value line for every leaf under prefix
+func flattenCanonical(prefix string, value interface{}, lines *[]string) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			*lines = append(*lines, prefix+"={}")
+		}
+		for key, child := range v {
+			if canonicalExcluded[key] {
+				continue
+			}
+			flattenCanonical(join(key), child, lines)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			*lines = append(*lines, prefix+"=[]")
+		}
+		for i, child := range v {
+			// Zero-pad indexes so sorted lines keep array order
+			flattenCanonical(join(fmt.Sprintf("%04d", i)), child, lines)
+		}
+	case string:
+		*lines = append(*lines, prefix+"="+strconv.Quote(v))
+	case nil:
+		*lines = append(*lines, prefix+"=null")
+	default:
+		*lines = append(*lines, fmt.Sprintf("%s=%v", prefix, v))
+	}
+}
@@ -0,0 +1,68 @@
+package reporter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tomi/media-parser-cli/internal/analyzer"
+	"github.com/tomi/media-parser-cli/internal/detector"
+)
+
+// githubCommand maps a problem severity to a GitHub Actions workflow command
+func githubCommand(severity detector.Severity) string {
+	switch severity {
+	case detector.SeverityCritical, detector.SeverityError:
+		return "error"
+	case detector.SeverityWarning:
+		return "warning"
+	default:
+		return "notice"
+	}
+}
+
+// githubEscapeData escapes a workflow command message
+func githubEscapeData(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	return strings.ReplaceAll(s, "\n", "%0A")
+}
+
+// githubEscapeProperty escapes a workflow command property value, which
+// additionally may not contain the property separators
+func githubEscapeProperty(s string) string {
+	s = githubEscapeData(s)
+	s = strings.ReplaceAll(s, ":", "%3A")
+	return strings.ReplaceAll(s, ",", "%2C")
+}
+
+// printDetailedGitHub writes one ::error/::warning/::notice workflow command
+// per problem so they surface as annotations in the GitHub Actions UI
+func (r *Reporter) printDetailedGitHub(analysis *analyzer.DetailedAnalysis) error {
+	input := ""
+	if analysis.MediaInfo != nil {
+		input = analysis.MediaInfo.Input
+	}
+
+	for _, problem := range analysis.Problems {
+		message := problem.Message
+		if problem.Timestamp > 0 {
+			message += fmt.Sprintf(" (at %.3fs)", problem.Timestamp)
+		}
+		if problem.Details != "" {
+			message += "\n" + problem.Details
+		}
+		if problem.Suggestion != "" {
+			message += "\nSuggestion: " + problem.Suggestion
+		}
+
+		_, err := fmt.Fprintf(r.writer, "::%s file=%s,title=%s::%s\n",
+			githubCommand(problem.Severity),
+			githubEscapeProperty(input),
+			githubEscapeProperty(problem.Code),
+			githubEscapeData(message))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
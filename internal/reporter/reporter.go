@@ -4,13 +4,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"os"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"text/tabwriter"
 	"time"
 
 	"github.com/tomi/media-parser-cli/internal/analyzer"
 	"github.com/tomi/media-parser-cli/internal/detector"
+	"gopkg.in/yaml.v3"
 )
 
 type Format int
@@ -19,12 +24,15 @@ const (
 	FormatText Format = iota
 	FormatJSON
 	FormatYAML
+	FormatGitHub // GitHub Actions workflow commands, one per problem
 )
 
 type Options struct {
 	Format       Format
 	Verbose      bool
 	ShowProblems bool
+	Sections     []string // Restrict output to these sections; empty prints everything
+	ShowAllAudio bool     // List every audio track instead of only the first
 }
 
 type Reporter struct {
@@ -39,12 +47,20 @@ func New(options Options) *Reporter {
 	}
 }
 
+// SetWriter sends the report to w instead of stdout
+func (r *Reporter) SetWriter(w io.Writer) {
+	r.writer = w
+}
+
 func (r *Reporter) Print(info *analyzer.MediaInfo) error {
 	switch r.options.Format {
 	case FormatJSON:
 		return r.printJSON(info)
 	case FormatYAML:
 		return r.printYAML(info)
+	case FormatGitHub:
+		// Annotations only carry problems, so there is nothing to emit
+		return nil
 	case FormatText:
 		return r.printText(info)
 	default:
@@ -53,93 +69,209 @@ func (r *Reporter) Print(info *analyzer.MediaInfo) error {
 }
 
 func (r *Reporter) printJSON(info *analyzer.MediaInfo) error {
+	if len(r.options.Sections) > 0 {
+		return r.printSections(info, nil)
+	}
 	encoder := json.NewEncoder(r.writer)
 	encoder.SetIndent("", "  ")
 	return encoder.Encode(info)
 }
 
 func (r *Reporter) printYAML(info *analyzer.MediaInfo) error {
-	jsonData, err := json.Marshal(info)
+	if len(r.options.Sections) > 0 {
+		return r.printSections(info, nil)
+	}
+	return r.encodeYAML(info)
+}
+
+// encodeYAML writes v as a YAML document. v is encoded through its JSON form
+// so YAML keys, omitted fields and custom encodings match the JSON output;
+// decoding that JSON into a yaml.Node keeps the struct field order, and
+// clearing the JSON styling lets the encoder quote only the strings that
+// would otherwise read as another type (timestamps, "1:1", "yes", ...).
+func (r *Reporter) encodeYAML(v interface{}) error {
+	jsonData, err := json.Marshal(v)
 	if err != nil {
 		return err
 	}
 
-	var data map[string]interface{}
-	if err := json.Unmarshal(jsonData, &data); err != nil {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(jsonData, &doc); err != nil {
 		return err
 	}
+	clearYAMLStyle(&doc)
 
-	return r.printYAMLMap(data, 0)
+	encoder := yaml.NewEncoder(r.writer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return err
+	}
+	return encoder.Close()
 }
 
-func (r *Reporter) printYAMLMap(data interface{}, indent int) error {
-	indentStr := strings.Repeat("  ", indent)
+// yaml11Sexagesimal matches strings YAML 1.1 parsers read as base-60
+// numbers, such as aspect ratios ("16:9") and timecodes
+var yaml11Sexagesimal = regexp.MustCompile(`^[-+]?[0-9][0-9_]*(:[0-5]?[0-9])+(\.[0-9_]*)?$`)
 
-	switch v := data.(type) {
-	case map[string]interface{}:
-		for key, value := range v {
-			if value == nil {
-				continue
-			}
-			fmt.Fprintf(r.writer, "%s%s:", indentStr, key)
-			if mapVal, ok := value.(map[string]interface{}); ok && len(mapVal) > 0 {
-				fmt.Fprintln(r.writer)
-				r.printYAMLMap(value, indent+1)
-			} else if a, ok := value.([]interface{}); ok && len(a) > 0 {
-				fmt.Fprintln(r.writer)
-				for _, item := range a {
-					fmt.Fprintf(r.writer, "%s- ", strings.Repeat("  ", indent+1))
-					if _, ok := item.(map[string]interface{}); ok {
-						fmt.Fprintln(r.writer)
-						r.printYAMLMap(item, indent+2)
-					} else {
-						fmt.Fprintln(r.writer, item)
-					}
-				}
-			} else {
-				fmt.Fprintf(r.writer, " %v\n", value)
-			}
-		}
-	default:
-		fmt.Fprintf(r.writer, "%s%v\n", indentStr, v)
-	}
+// yaml11Bools are the plain scalars YAML 1.1 parsers read as booleans
+var yaml11Bools = map[string]bool{
+	"y": true, "yes": true, "n": true, "no": true, "on": true, "off": true,
+}
 
-	return nil
+// clearYAMLStyle resets the flow and quoting styles carried over from JSON
+// so the document is written in block style. Strings that YAML 1.1 parsers
+// (e.g. PyYAML) would read as numbers or booleans stay quoted, since the
+// encoder only quotes by YAML 1.2 rules.
+func clearYAMLStyle(node *yaml.Node) {
+	keepQuotes := node.Kind == yaml.ScalarNode && node.Tag == "!!str" &&
+		(yaml11Sexagesimal.MatchString(node.Value) || yaml11Bools[strings.ToLower(node.Value)])
+	if !keepQuotes {
+		node.Style = 0
+	}
+	for _, child := range node.Content {
+		clearYAMLStyle(child)
+	}
 }
 
 func (r *Reporter) printText(info *analyzer.MediaInfo) error {
+	if len(r.options.Sections) > 0 {
+		r.printTextSections(info)
+		return nil
+	}
+
 	fmt.Fprintln(r.writer, strings.Repeat("=", 80))
 	fmt.Fprintf(r.writer, "MEDIA ANALYSIS REPORT\n")
 	fmt.Fprintf(r.writer, "Analyzed at: %s\n", info.AnalyzedAt.Format(time.RFC3339))
 	fmt.Fprintf(r.writer, "Input: %s\n", info.Input)
+	if info.StartTimecode != "" {
+		fmt.Fprintf(r.writer, "Start Timecode: %s\n", info.StartTimecode)
+	}
+	if info.FormatHint != nil {
+		matched := "matched"
+		if !info.FormatHint.Matched {
+			matched = "did not match"
+		}
+		fmt.Fprintf(r.writer, "Format Hint: %s (%s)\n", info.FormatHint.Format, matched)
+	}
+	if info.AbsolutePath != "" {
+		fmt.Fprintf(r.writer, "Path: %s\n", info.AbsolutePath)
+	}
+	if info.ContentHash != "" {
+		fmt.Fprintf(r.writer, "SHA-256: %s\n", info.ContentHash)
+	}
+	if info.Fingerprint != "" {
+		fmt.Fprintf(r.writer, "Fingerprint: %s\n", info.Fingerprint)
+	}
+	fmt.Fprintln(r.writer, strings.Repeat("=", 80))
+
+	r.printTextSections(info)
+
+	if len(info.FFprobeLog) > 0 {
+		fmt.Fprintln(r.writer, "\nFFPROBE DIAGNOSTICS:")
+		fmt.Fprintln(r.writer, strings.Repeat("-", 40))
+		for _, entry := range info.FFprobeLog {
+			if entry.Count > 1 {
+				fmt.Fprintf(r.writer, "  %s (x%d)\n", entry.Message, entry.Count)
+			} else {
+				fmt.Fprintf(r.writer, "  %s\n", entry.Message)
+			}
+		}
+	}
+
 	fmt.Fprintln(r.writer, strings.Repeat("=", 80))
+	return nil
+}
 
-	if info.Format != nil {
+// printTextSections prints the info blocks selected by Options.Sections
+func (r *Reporter) printTextSections(info *analyzer.MediaInfo) {
+	if info.Format != nil && r.hasSection(SectionFormat) {
 		fmt.Fprintln(r.writer, "\nCONTAINER FORMAT:")
 		fmt.Fprintln(r.writer, strings.Repeat("-", 40))
 		r.printFormatInfo(info.Format)
 	}
 
-	if info.VideoStream != nil {
+	if len(info.Renditions) > 0 && r.hasSection(SectionFormat) {
+		fmt.Fprintf(r.writer, "\nRENDITIONS (%d):\n", len(info.Renditions))
+		fmt.Fprintln(r.writer, strings.Repeat("-", 40))
+		r.printRenditionsTable(info.Renditions)
+	}
+
+	if len(info.VideoStreams) > 1 && r.hasSection(SectionVideo) {
+		fmt.Fprintf(r.writer, "\nVIDEO STREAMS (%d):\n", len(info.VideoStreams))
+		fmt.Fprintln(r.writer, strings.Repeat("-", 40))
+		for i := range info.VideoStreams {
+			if i > 0 {
+				fmt.Fprintln(r.writer)
+			}
+			r.printVideoInfo(&info.VideoStreams[i])
+		}
+	} else if info.VideoStream != nil && r.hasSection(SectionVideo) {
 		fmt.Fprintln(r.writer, "\nVIDEO STREAM:")
 		fmt.Fprintln(r.writer, strings.Repeat("-", 40))
 		r.printVideoInfo(info.VideoStream)
 	}
 
-	if info.AudioStream != nil {
+	if r.options.ShowAllAudio && len(info.AudioStreams) > 1 && r.hasSection(SectionAudio) {
+		fmt.Fprintf(r.writer, "\nAUDIO STREAMS (%d):\n", len(info.AudioStreams))
+		fmt.Fprintln(r.writer, strings.Repeat("-", 40))
+		r.printAudioTracksTable(info.AudioStreams)
+	} else if info.AudioStream != nil && r.hasSection(SectionAudio) {
 		fmt.Fprintln(r.writer, "\nAUDIO STREAM:")
 		fmt.Fprintln(r.writer, strings.Repeat("-", 40))
 		r.printAudioInfo(info.AudioStream)
+		if extra := len(info.AudioStreams) - 1; extra > 0 {
+			fmt.Fprintf(r.writer, "(+%d more audio streams, use --show-all-audio to list them)\n", extra)
+		}
 	}
 
-	if len(info.Streams) > 0 {
+	if info.CoverArt != nil && r.hasSection(SectionVideo) {
+		fmt.Fprintln(r.writer, "\nCOVER ART:")
+		fmt.Fprintln(r.writer, strings.Repeat("-", 40))
+		fmt.Fprintf(r.writer, "Stream %d: %s %dx%d\n", info.CoverArt.Index, info.CoverArt.Codec, info.CoverArt.Width, info.CoverArt.Height)
+	}
+
+	if len(info.DataStreams) > 0 && r.hasSection(SectionStreams) {
+		fmt.Fprintf(r.writer, "\nDATA STREAMS (%d):\n", len(info.DataStreams))
+		fmt.Fprintln(r.writer, strings.Repeat("-", 40))
+		for _, data := range info.DataStreams {
+			fmt.Fprintf(r.writer, "Stream %d: %s", data.Index, data.Type)
+			if data.CodecTagString != "" && data.CodecTagString != "[0][0][0][0]" {
+				fmt.Fprintf(r.writer, " (tag %s)", data.CodecTagString)
+			}
+			fmt.Fprintln(r.writer)
+		}
+	}
+
+	if len(info.Streams) > 0 && r.hasSection(SectionStreams) {
 		fmt.Fprintln(r.writer, "\nALL STREAMS:")
 		fmt.Fprintln(r.writer, strings.Repeat("-", 40))
 		r.printStreamsTable(info.Streams)
 	}
+}
 
-	fmt.Fprintln(r.writer, strings.Repeat("=", 80))
-	return nil
+func (r *Reporter) printRenditionsTable(renditions []analyzer.RenditionInfo) {
+	w := tabwriter.NewWriter(r.writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Program\tBitrate\tVideo\tResolution\tAudio")
+	for _, rendition := range renditions {
+		bitrate := "-"
+		if rendition.Bitrate > 0 {
+			bitrate = r.formatBitrate(rendition.Bitrate)
+		}
+		resolution := "-"
+		if rendition.Width > 0 && rendition.Height > 0 {
+			resolution = fmt.Sprintf("%dx%d", rendition.Width, rendition.Height)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", rendition.ProgramID, bitrate, dashIfEmpty(rendition.VideoCodec), resolution, dashIfEmpty(rendition.AudioCodec))
+	}
+	w.Flush()
+}
+
+// dashIfEmpty returns "-" for empty table cells
+func dashIfEmpty(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
 }
 
 func (r *Reporter) printFormatInfo(format *analyzer.FormatInfo) {
@@ -147,7 +279,11 @@ func (r *Reporter) printFormatInfo(format *analyzer.FormatInfo) {
 	fmt.Fprintf(w, "Format:\t%s\n", format.FormatName)
 	fmt.Fprintf(w, "Long Name:\t%s\n", format.FormatLongName)
 	if format.Duration > 0 {
-		fmt.Fprintf(w, "Duration:\t%s\n", r.formatDuration(format.Duration))
+		if format.DurationSource == analyzer.DurationSourceStream {
+			fmt.Fprintf(w, "Duration:\t%s (from stream, container has none)\n", r.formatDuration(format.Duration))
+		} else {
+			fmt.Fprintf(w, "Duration:\t%s\n", r.formatDuration(format.Duration))
+		}
 	}
 	if format.Size > 0 {
 		fmt.Fprintf(w, "File Size:\t%s\n", r.formatSize(format.Size))
@@ -172,6 +308,9 @@ func (r *Reporter) printVideoInfo(video *analyzer.VideoInfo) {
 	if video.AspectRatio != "" {
 		fmt.Fprintf(w, "Aspect Ratio:\t%s\n", video.AspectRatio)
 	}
+	if video.SampleAspectRatio != "" && video.SampleAspectRatio != "1:1" {
+		fmt.Fprintf(w, "Sample Aspect:\t%s\n", video.SampleAspectRatio)
+	}
 	fmt.Fprintf(w, "Pixel Format:\t%s\n", video.PixelFormat)
 	fmt.Fprintf(w, "Frame Rate:\t%s fps\n", video.FrameRate)
 	if video.AvgFrameRate != "" && video.AvgFrameRate != video.FrameRate {
@@ -180,12 +319,18 @@ func (r *Reporter) printVideoInfo(video *analyzer.VideoInfo) {
 	if video.Bitrate > 0 {
 		fmt.Fprintf(w, "Bitrate:\t%s\n", r.formatBitrate(video.Bitrate))
 	}
+	if video.MeasuredBitrate > 0 {
+		fmt.Fprintf(w, "Measured Bitrate:\t%s\n", r.formatBitrate(video.MeasuredBitrate))
+	}
 	if video.Duration > 0 {
 		fmt.Fprintf(w, "Duration:\t%s\n", r.formatDuration(video.Duration))
 	}
 	if video.FrameCount > 0 {
 		fmt.Fprintf(w, "Total Frames:\t%d\n", video.FrameCount)
 	}
+	if video.Stereo3D != "" {
+		fmt.Fprintf(w, "Stereo 3D:\t%s\n", video.Stereo3D)
+	}
 	if r.options.Verbose {
 		if video.ColorSpace != "" {
 			fmt.Fprintf(w, "Color Space:\t%s\n", video.ColorSpace)
@@ -196,9 +341,18 @@ func (r *Reporter) printVideoInfo(video *analyzer.VideoInfo) {
 		if video.ColorTransfer != "" {
 			fmt.Fprintf(w, "Color Transfer:\t%s\n", video.ColorTransfer)
 		}
+		if video.HDR != nil {
+			fmt.Fprintf(w, "HDR Metadata:\t%s\n", video.HDR.Describe())
+		}
 		if video.HasBFrames > 0 {
 			fmt.Fprintf(w, "Has B-Frames:\t%d\n", video.HasBFrames)
 		}
+		if video.CodecTagString != "" {
+			fmt.Fprintf(w, "Codec Tag:\t%s\n", video.CodecTagString)
+		}
+		if video.Encoder != "" {
+			fmt.Fprintf(w, "Encoder:\t%s\n", video.Encoder)
+		}
 	}
 	w.Flush()
 }
@@ -222,9 +376,49 @@ func (r *Reporter) printAudioInfo(audio *analyzer.AudioInfo) {
 	if audio.Duration > 0 {
 		fmt.Fprintf(w, "Duration:\t%s\n", r.formatDuration(audio.Duration))
 	}
+	if r.options.Verbose && audio.Encoder != "" {
+		fmt.Fprintf(w, "Encoder:\t%s\n", audio.Encoder)
+	}
+	w.Flush()
+}
+
+// printAudioTracksTable lists every audio track with its language, for
+// inventorying multi-language deliverables
+func (r *Reporter) printAudioTracksTable(tracks []analyzer.AudioInfo) {
+	w := tabwriter.NewWriter(r.writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Index\tLanguage\tCodec\tChannels\tLayout\tSample Rate\tBitrate\tFlags\n")
+	fmt.Fprintf(w, "-----\t--------\t-----\t--------\t------\t-----------\t-------\t-----\n")
+	for _, track := range tracks {
+		language := track.Language
+		if language == "" {
+			language = "und"
+		}
+		bitrate := "-"
+		if track.Bitrate > 0 {
+			bitrate = r.formatBitrate(track.Bitrate)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%s\t%d Hz\t%s\t%s\n",
+			track.Index, language, track.Codec, track.Channels, track.ChannelLayout, track.SampleRate, bitrate,
+			dispositionFlags(track.Default, track.Forced))
+	}
 	w.Flush()
 }
 
+// dispositionFlags lists a track's default and forced dispositions, or "-"
+func dispositionFlags(isDefault, forced bool) string {
+	var flags []string
+	if isDefault {
+		flags = append(flags, "default")
+	}
+	if forced {
+		flags = append(flags, "forced")
+	}
+	if len(flags) == 0 {
+		return "-"
+	}
+	return strings.Join(flags, ",")
+}
+
 func (r *Reporter) printStreamsTable(streams []analyzer.StreamInfo) {
 	w := tabwriter.NewWriter(r.writer, 0, 0, 2, ' ', 0)
 	fmt.Fprintf(w, "Index\tType\tCodec\tTags\n")
@@ -277,6 +471,64 @@ func (r *Reporter) formatBitrate(bps int64) string {
 	}
 }
 
+// PrintSummaryLine writes summary as a single compact JSON object followed
+// by a newline (NDJSON), so records can be streamed into bulk loaders
+func (r *Reporter) PrintSummaryLine(summary *analyzer.Summary) error {
+	line, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+	_, err = r.writer.Write(append(line, '\n'))
+	return err
+}
+
+// PrintOneline writes a single space-separated status line:
+//
+//	STATUS INPUT VIDEO_CODEC WIDTHxHEIGHT <n>errors
+//
+// STATUS is FAIL when there is any error or critical problem, OK otherwise.
+// Missing fields are printed as "-" and inputs containing whitespace are
+// quoted, so every line has exactly five fields.
+func (r *Reporter) PrintOneline(summary *analyzer.Summary) error {
+	_, err := fmt.Fprintln(r.writer, onelineStatus(summary), onelineInput(summary.Input), onelineVideo(summary), fmt.Sprintf("%derrors", summary.Errors()))
+	return err
+}
+
+// PrintOnelineFailure writes the status line for an input that could not be
+// analyzed at all
+func (r *Reporter) PrintOnelineFailure(input string) error {
+	_, err := fmt.Fprintln(r.writer, "FAIL", onelineInput(input), "- - -")
+	return err
+}
+
+// onelineStatus returns OK or FAIL for summary
+func onelineStatus(summary *analyzer.Summary) string {
+	if summary.Errors() > 0 {
+		return "FAIL"
+	}
+	return "OK"
+}
+
+// onelineInput quotes inputs that would otherwise split into several fields
+func onelineInput(input string) string {
+	if strings.ContainsAny(input, " \t\n\"") {
+		return strconv.Quote(input)
+	}
+	return input
+}
+
+// onelineVideo returns the video codec and resolution fields
+func onelineVideo(summary *analyzer.Summary) string {
+	codec, resolution := "-", "-"
+	if summary.VideoCodec != "" {
+		codec = summary.VideoCodec
+	}
+	if summary.Width > 0 && summary.Height > 0 {
+		resolution = fmt.Sprintf("%dx%d", summary.Width, summary.Height)
+	}
+	return codec + " " + resolution
+}
+
 // PrintDetailed prints detailed analysis including problems
 func (r *Reporter) PrintDetailed(analysis *analyzer.DetailedAnalysis) error {
 	switch r.options.Format {
@@ -284,6 +536,8 @@ func (r *Reporter) PrintDetailed(analysis *analyzer.DetailedAnalysis) error {
 		return r.printDetailedJSON(analysis)
 	case FormatYAML:
 		return r.printDetailedYAML(analysis)
+	case FormatGitHub:
+		return r.printDetailedGitHub(analysis)
 	case FormatText:
 		return r.printDetailedText(analysis)
 	default:
@@ -292,23 +546,19 @@ func (r *Reporter) PrintDetailed(analysis *analyzer.DetailedAnalysis) error {
 }
 
 func (r *Reporter) printDetailedJSON(analysis *analyzer.DetailedAnalysis) error {
+	if len(r.options.Sections) > 0 {
+		return r.printSections(analysis.MediaInfo, analysis.Problems)
+	}
 	encoder := json.NewEncoder(r.writer)
 	encoder.SetIndent("", "  ")
 	return encoder.Encode(analysis)
 }
 
 func (r *Reporter) printDetailedYAML(analysis *analyzer.DetailedAnalysis) error {
-	jsonData, err := json.Marshal(analysis)
-	if err != nil {
-		return err
+	if len(r.options.Sections) > 0 {
+		return r.printSections(analysis.MediaInfo, analysis.Problems)
 	}
-
-	var data map[string]interface{}
-	if err := json.Unmarshal(jsonData, &data); err != nil {
-		return err
-	}
-
-	return r.printYAMLMap(data, 0)
+	return r.encodeYAML(analysis)
 }
 
 func (r *Reporter) printDetailedText(analysis *analyzer.DetailedAnalysis) error {
@@ -317,69 +567,137 @@ func (r *Reporter) printDetailedText(analysis *analyzer.DetailedAnalysis) error
 		return err
 	}
 
+	if len(analysis.BitrateTimeline) > 0 && len(r.options.Sections) == 0 {
+		fmt.Fprintln(r.writer, "\nBITRATE TIMELINE:")
+		fmt.Fprintln(r.writer, strings.Repeat("-", 40))
+		r.printBitrateSparkline(analysis.BitrateTimeline)
+		if analysis.PeakBitrate > 0 {
+			fmt.Fprintf(r.writer, "Peak (%gs window): %s\n", analysis.PeakBitrateWindow, r.formatBitrate(int64(analysis.PeakBitrate)))
+		}
+	}
+
+	if stats := analysis.VideoStats; stats != nil && len(r.options.Sections) == 0 {
+		fmt.Fprintln(r.writer, "\nVIDEO BITRATE (1s windows):")
+		fmt.Fprintln(r.writer, strings.Repeat("-", 40))
+		w := tabwriter.NewWriter(r.writer, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "Average:\t%s\n", r.formatBitrate(stats.AvgBitrate))
+		fmt.Fprintf(w, "Peak:\t%s at %.2fs\n", r.formatBitrate(stats.PeakBitrate), stats.PeakTime)
+		fmt.Fprintf(w, "Minimum:\t%s at %.2fs\n", r.formatBitrate(stats.MinBitrate), stats.MinTime)
+		w.Flush()
+	}
+
 	// Then print detected problems
-	if r.options.ShowProblems && len(analysis.Problems) > 0 {
+	if r.options.ShowProblems && len(analysis.Problems) > 0 && r.hasSection(SectionProblems) {
 		fmt.Fprintln(r.writer, "\nDETECTED PROBLEMS:")
 		fmt.Fprintln(r.writer, strings.Repeat("-", 40))
 		r.printProblems(analysis.Problems)
 	}
 
+	if r.options.ShowProblems && len(analysis.Resolved) > 0 && r.hasSection(SectionProblems) {
+		fmt.Fprintln(r.writer, "\nRESOLVED SINCE BASELINE:")
+		fmt.Fprintln(r.writer, strings.Repeat("-", 40))
+		for _, p := range analysis.Resolved {
+			fmt.Fprintf(r.writer, "  ✅ [%s] %s\n", p.Code, p.Message)
+		}
+	}
+
 	return nil
 }
 
-func (r *Reporter) printProblems(problems []detector.Problem) {
-	// Group problems by severity
-	var errors, criticals, warnings, infos []detector.Problem
-
-	for _, p := range problems {
-		switch p.Severity {
-		case detector.SeverityError:
-			errors = append(errors, p)
-		case detector.SeverityCritical:
-			criticals = append(criticals, p)
-		case detector.SeverityWarning:
-			warnings = append(warnings, p)
-		case detector.SeverityInfo:
-			infos = append(infos, p)
-		}
-	}
+// sparkBlocks are the glyphs used for sparklines, from lowest to highest
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
 
-	// Print errors first
-	if len(errors) > 0 {
-		fmt.Fprintln(r.writer, "\n🔴 ERRORS:")
-		for _, p := range errors {
-			r.printProblem(p)
-		}
-	}
+// printBitrateSparkline renders the total bitrate timeline as a single line
+// of block characters, averaging points into at most 60 columns
+func (r *Reporter) printBitrateSparkline(timeline []detector.BitratePoint) {
+	const maxWidth = 60
 
-	// Then critical issues
-	if len(criticals) > 0 {
-		fmt.Fprintln(r.writer, "\n🟠 CRITICAL:")
-		for _, p := range criticals {
-			r.printProblem(p)
+	values := make([]float64, 0, len(timeline))
+	var start, end float64
+	for _, point := range timeline {
+		if point.Type != "total" {
+			continue
+		}
+		if len(values) == 0 {
+			start = point.Time
+		}
+		end = point.Time
+		values = append(values, point.Bitrate)
+	}
+	if len(values) == 0 {
+		return
+	}
+
+	minBitrate, maxBitrate, sum := values[0], values[0], 0.0
+	for _, v := range values {
+		minBitrate = math.Min(minBitrate, v)
+		maxBitrate = math.Max(maxBitrate, v)
+		sum += v
+	}
+	avgBitrate := sum / float64(len(values))
+
+	columns := values
+	if len(values) > maxWidth {
+		columns = make([]float64, maxWidth)
+		for i := range columns {
+			from := i * len(values) / maxWidth
+			to := (i + 1) * len(values) / maxWidth
+			var total float64
+			for _, v := range values[from:to] {
+				total += v
+			}
+			columns[i] = total / float64(to-from)
 		}
 	}
 
-	// Then warnings
-	if len(warnings) > 0 {
-		fmt.Fprintln(r.writer, "\n🟡 WARNINGS:")
-		for _, p := range warnings {
-			r.printProblem(p)
+	var line strings.Builder
+	for _, v := range columns {
+		level := len(sparkBlocks) - 1
+		if maxBitrate > minBitrate {
+			level = int((v - minBitrate) / (maxBitrate - minBitrate) * float64(len(sparkBlocks)-1))
 		}
+		line.WriteRune(sparkBlocks[level])
 	}
 
-	// Finally info
-	if len(infos) > 0 && r.options.Verbose {
-		fmt.Fprintln(r.writer, "\n🔵 INFO:")
-		for _, p := range infos {
-			r.printProblem(p)
+	fmt.Fprintf(r.writer, "%s\n", line.String())
+	fmt.Fprintf(r.writer, "%s .. %s\n", r.formatDuration(start), r.formatDuration(end))
+	fmt.Fprintf(r.writer, "Min: %s  Avg: %s  Max: %s\n",
+		r.formatBitrate(int64(minBitrate)), r.formatBitrate(int64(avgBitrate)), r.formatBitrate(int64(maxBitrate)))
+}
+
+// severityHeadings are the text report headings for each severity
+var severityHeadings = map[detector.Severity]string{
+	detector.SeverityCritical: "🔴 CRITICAL:",
+	detector.SeverityError:    "🟠 ERRORS:",
+	detector.SeverityWarning:  "🟡 WARNINGS:",
+	detector.SeverityInfo:     "🔵 INFO:",
+}
+
+// printProblems prints problems grouped by severity, most severe first as
+// ordered by Severity.Rank. Info problems are only shown in verbose mode.
+func (r *Reporter) printProblems(problems []detector.Problem) {
+	sorted := make([]detector.Problem, len(problems))
+	copy(sorted, problems)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Severity.Rank() > sorted[j].Severity.Rank()
+	})
+
+	counts := make(map[detector.Severity]int)
+	for i, p := range sorted {
+		counts[p.Severity]++
+		if p.Severity == detector.SeverityInfo && !r.options.Verbose {
+			continue
+		}
+		if i == 0 || sorted[i-1].Severity != p.Severity {
+			fmt.Fprintln(r.writer, "\n"+severityHeadings[p.Severity])
 		}
+		r.printProblem(p)
 	}
 
 	// Summary
 	fmt.Fprintln(r.writer, "\n" + strings.Repeat("-", 40))
-	fmt.Fprintf(r.writer, "Summary: %d errors, %d critical, %d warnings, %d info\n",
-		len(errors), len(criticals), len(warnings), len(infos))
+	fmt.Fprintf(r.writer, "Summary: %d critical, %d errors, %d warnings, %d info\n",
+		counts[detector.SeverityCritical], counts[detector.SeverityError], counts[detector.SeverityWarning], counts[detector.SeverityInfo])
 }
 
 func (r *Reporter) printProblem(p detector.Problem) {
@@ -398,6 +716,10 @@ func (r *Reporter) printProblem(p detector.Problem) {
 	if p.Timestamp > 0 {
 		fmt.Fprintf(w, "    Timestamp:\t%.2fs\n", p.Timestamp)
 	}
+
+	if p.OriginalSeverity != nil {
+		fmt.Fprintf(w, "    Severity:\t%s (overridden from %s)\n", p.Severity, *p.OriginalSeverity)
+	}
 	
 	w.Flush()
 	fmt.Fprintln(r.writer)
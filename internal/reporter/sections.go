@@ -0,0 +1,98 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/tomi/media-parser-cli/internal/analyzer"
+	"github.com/tomi/media-parser-cli/internal/detector"
+)
+
+// Report sections selectable with Options.Sections
+const (
+	SectionFormat   = "format"
+	SectionVideo    = "video"
+	SectionAudio    = "audio"
+	SectionStreams  = "streams"
+	SectionProblems = "problems"
+)
+
+// SectionNames lists the valid section names in report order
+var SectionNames = []string{SectionFormat, SectionVideo, SectionAudio, SectionStreams, SectionProblems}
+
+// ValidateSections normalizes section names, returning an error for any
+// name that is not a known section
+func ValidateSections(sections []string) ([]string, error) {
+	normalized := make([]string, 0, len(sections))
+	for _, section := range sections {
+		name := strings.ToLower(strings.TrimSpace(section))
+		known := false
+		for _, valid := range SectionNames {
+			if name == valid {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown section %q (valid sections: %s)", section, strings.Join(SectionNames, ", "))
+		}
+		normalized = append(normalized, name)
+	}
+	return normalized, nil
+}
+
+// hasSection reports whether a section should be printed. All sections are
+// printed when none were selected.
+func (r *Reporter) hasSection(name string) bool {
+	if len(r.options.Sections) == 0 {
+		return true
+	}
+	for _, section := range r.options.Sections {
+		if section == name {
+			return true
+		}
+	}
+	return false
+}
+
+// sectionData collects the selected sections keyed by their JSON name
+func (r *Reporter) sectionData(info *analyzer.MediaInfo, problems []detector.Problem) map[string]interface{} {
+	data := make(map[string]interface{}, len(r.options.Sections))
+	for _, section := range r.options.Sections {
+		switch section {
+		case SectionFormat:
+			data[section] = info.Format
+		case SectionVideo:
+			data[section] = info.VideoStream
+		case SectionAudio:
+			data[section] = info.AudioStream
+		case SectionStreams:
+			data[section] = info.Streams
+		case SectionProblems:
+			if problems == nil {
+				problems = []detector.Problem{}
+			}
+			data[section] = problems
+		}
+	}
+	return data
+}
+
+// printSections writes only the selected sections. JSON output for a single
+// section is the bare object, so `--section video -o json` prints just the
+// video object; multiple sections are keyed by section name.
+func (r *Reporter) printSections(info *analyzer.MediaInfo, problems []detector.Problem) error {
+	data := r.sectionData(info, problems)
+
+	if r.options.Format == FormatYAML {
+		return r.encodeYAML(data)
+	}
+
+	encoder := json.NewEncoder(r.writer)
+	encoder.SetIndent("", "  ")
+	if len(r.options.Sections) == 1 {
+		return encoder.Encode(data[r.options.Sections[0]])
+	}
+	return encoder.Encode(data)
+}
@@ -0,0 +1,72 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/tomi/media-parser-cli/internal/analyzer"
+	"github.com/tomi/media-parser-cli/internal/detector"
+)
+
+// ValidationReport is the outcome of checking one input against a profile
+type ValidationReport struct {
+	Input      string             `json:"input"`
+	Profile    string             `json:"profile"`
+	Passed     bool               `json:"passed"`
+	Violations []detector.Problem `json:"violations"`
+}
+
+// NewValidationReport collects the profile violations among the analysis'
+// problems. The input passes when there are none.
+func NewValidationReport(analysis *analyzer.DetailedAnalysis, profile *detector.Profile) *ValidationReport {
+	report := &ValidationReport{
+		Profile:    profile.Name,
+		Violations: make([]detector.Problem, 0),
+	}
+	if analysis.MediaInfo != nil {
+		report.Input = analysis.MediaInfo.Input
+	}
+	for _, problem := range analysis.Problems {
+		if detector.IsProfileProblem(problem.Code) {
+			report.Violations = append(report.Violations, problem)
+		}
+	}
+	report.Passed = len(report.Violations) == 0
+	return report
+}
+
+// PrintValidation prints a validation report in the configured format
+func (r *Reporter) PrintValidation(report *ValidationReport) error {
+	switch r.options.Format {
+	case FormatJSON:
+		encoder := json.NewEncoder(r.writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case FormatYAML:
+		return r.encodeYAML(report)
+	default:
+		return r.printValidationText(report)
+	}
+}
+
+func (r *Reporter) printValidationText(report *ValidationReport) error {
+	fmt.Fprintf(r.writer, "Input:   %s\n", report.Input)
+	fmt.Fprintf(r.writer, "Profile: %s\n", report.Profile)
+
+	if len(report.Violations) > 0 {
+		fmt.Fprintln(r.writer, "\nVIOLATIONS:")
+		fmt.Fprintln(r.writer, strings.Repeat("-", 40))
+		for _, problem := range report.Violations {
+			r.printProblem(problem)
+		}
+	}
+
+	fmt.Fprintln(r.writer, "\n"+strings.Repeat("-", 40))
+	if report.Passed {
+		fmt.Fprintf(r.writer, "PASS: meets the %s profile\n", report.Profile)
+	} else {
+		fmt.Fprintf(r.writer, "FAIL: %d violation(s) of the %s profile\n", len(report.Violations), report.Profile)
+	}
+	return nil
+}
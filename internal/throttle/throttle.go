@@ -0,0 +1,76 @@
+// Package throttle limits how often and how many remote inputs are probed at
+// once, so large scans do not hammer an origin server or CDN.
+package throttle
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter spaces out the start of remote analyses and caps how many run at
+// the same time. The zero limits disable the respective check.
+type Limiter struct {
+	interval time.Duration // Minimum gap between starts; 0 is unlimited
+	slots    chan struct{} // Concurrency tokens; nil is unlimited
+
+	mu   sync.Mutex
+	next time.Time // Earliest time the next analysis may start
+}
+
+// New returns a limiter allowing perSecond starts per second and at most
+// maxConcurrent analyses in flight
+func New(perSecond float64, maxConcurrent int) *Limiter {
+	l := &Limiter{}
+	if perSecond > 0 {
+		l.interval = time.Duration(float64(time.Second) / perSecond)
+	}
+	if maxConcurrent > 0 {
+		l.slots = make(chan struct{}, maxConcurrent)
+	}
+	return l
+}
+
+// Acquire blocks until an analysis may start and reports how long it was
+// held back. The returned release must be called when the analysis ends.
+func (l *Limiter) Acquire(ctx context.Context) (release func(), waited time.Duration, err error) {
+	start := time.Now()
+
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, time.Since(start), ctx.Err()
+		}
+	}
+	release = func() {
+		if l.slots != nil {
+			<-l.slots
+		}
+	}
+
+	if l.interval > 0 {
+		// Reserve the next start slot, then sleep until it comes up
+		l.mu.Lock()
+		now := time.Now()
+		slot := l.next
+		if slot.Before(now) {
+			slot = now
+		}
+		l.next = slot.Add(l.interval)
+		l.mu.Unlock()
+
+		if delay := time.Until(slot); delay > 0 {
+			timer := time.NewTimer(delay)
+			defer timer.Stop()
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				release()
+				return nil, time.Since(start), ctx.Err()
+			}
+		}
+	}
+
+	return release, time.Since(start), nil
+}
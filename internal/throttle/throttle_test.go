@@ -0,0 +1,114 @@
+package throttle
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLimiterCapsConcurrency(t *testing.T) {
+	l := New(0, 2)
+
+	var inFlight, peak int32
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, _, err := l.Acquire(context.Background())
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer release()
+
+			n := atomic.AddInt32(&inFlight, 1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&inFlight, -1)
+		}()
+	}
+	wg.Wait()
+
+	if peak != 2 {
+		t.Errorf("got %d analyses in flight at once, want 2", peak)
+	}
+}
+
+func TestLimiterSpacesStarts(t *testing.T) {
+	l := New(20, 0) // One start every 50ms
+
+	start := time.Now()
+	var waited time.Duration
+	for i := 0; i < 4; i++ {
+		release, w, err := l.Acquire(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		release()
+		waited += w
+	}
+
+	// The first start is immediate, the other three wait their turn
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("4 starts took %v, want at least 150ms", elapsed)
+	}
+	if waited < 100*time.Millisecond {
+		t.Errorf("reported %v waited, want at least 100ms", waited)
+	}
+}
+
+func TestLimiterUnlimited(t *testing.T) {
+	l := New(0, 0)
+	for i := 0; i < 100; i++ {
+		release, waited, err := l.Acquire(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if waited > 10*time.Millisecond {
+			t.Fatalf("unlimited limiter held a start back for %v", waited)
+		}
+		// Never released: without a concurrency cap nothing blocks on it
+		_ = release
+	}
+}
+
+func TestLimiterCancel(t *testing.T) {
+	l := New(0, 1)
+	release, _, err := l.Acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, _, err := l.Acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v waiting on a full limiter, want context.DeadlineExceeded", err)
+	}
+
+	// A start cancelled while waiting for its rate slot frees its
+	// concurrency token again
+	l = New(1, 1)
+	first, _, err := l.Acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	first()
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, _, err := l.Acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v waiting on the rate limit, want context.DeadlineExceeded", err)
+	}
+	if len(l.slots) != 0 {
+		t.Errorf("cancelled start kept its concurrency token")
+	}
+}
@@ -0,0 +1,71 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+type Options struct {
+	URL        string
+	Timeout    time.Duration // Per-attempt timeout
+	AuthHeader string        // Sent verbatim as the Authorization header when set
+	Retries    int           // Additional attempts after a transient failure
+}
+
+// Result describes the outcome of a delivery
+type Result struct {
+	StatusCode int
+	Attempts   int
+}
+
+// Send POSTs payload as JSON to the configured URL. Network errors, 429 and
+// 5xx responses are treated as transient and retried with a linear backoff.
+func Send(options Options, payload interface{}) (*Result, error) {
+	result := &Result{}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return result, fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	client := &http.Client{Timeout: options.Timeout}
+
+	var lastErr error
+	for attempt := 0; attempt <= options.Retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * time.Second)
+		}
+		result.Attempts++
+
+		req, err := http.NewRequest(http.MethodPost, options.URL, bytes.NewReader(body))
+		if err != nil {
+			return result, fmt.Errorf("invalid webhook request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if options.AuthHeader != "" {
+			req.Header.Set("Authorization", options.AuthHeader)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		result.StatusCode = resp.StatusCode
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return result, nil
+		}
+		lastErr = fmt.Errorf("webhook returned %s", resp.Status)
+		if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+			break
+		}
+	}
+
+	return result, lastErr
+}
@@ -0,0 +1,97 @@
+package ffprobe
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// sensitiveQueryParams are URL query parameters whose values are redacted
+// when commands are shown
+var sensitiveQueryParams = []string{"token", "key", "signature", "sig", "password", "secret", "auth", "access_token"}
+
+// sensitiveHeaders are HTTP headers whose values are redacted when commands
+// are shown
+var sensitiveHeaders = []string{"authorization", "proxy-authorization", "cookie", "x-api-key"}
+
+// SetCommandWriter makes the prober print each ffprobe command line to w
+// before running it. Pass nil to disable.
+func (f *FFProbe) SetCommandWriter(w io.Writer) {
+	f.commandWriter = w
+}
+
+// logCommand prints the command line about to be executed, with
+// credentials redacted
+func (f *FFProbe) logCommand(args []string) {
+	if f.commandWriter == nil {
+		return
+	}
+	parts := []string{quoteArg(f.binary)}
+	for i, arg := range args {
+		if i > 0 && args[i-1] == "-headers" {
+			arg = redactHeaders(arg)
+		}
+		parts = append(parts, quoteArg(redactArg(arg)))
+	}
+	fmt.Fprintf(f.commandWriter, "$ %s\n", strings.Join(parts, " "))
+}
+
+// redactArg hides passwords in URL user info and the values of
+// credential-like query parameters
+func redactArg(arg string) string {
+	u, err := url.Parse(arg)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return arg
+	}
+
+	redacted := false
+	if u.User != nil {
+		if _, hasPassword := u.User.Password(); hasPassword {
+			u.User = url.UserPassword(u.User.Username(), "REDACTED")
+			redacted = true
+		}
+	}
+
+	query := u.Query()
+	for name := range query {
+		for _, sensitive := range sensitiveQueryParams {
+			if strings.EqualFold(name, sensitive) {
+				query.Set(name, "REDACTED")
+				redacted = true
+			}
+		}
+	}
+	if !redacted {
+		return arg
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// redactHeaders hides the values of credential headers in a CRLF-separated
+// -headers argument
+func redactHeaders(headers string) string {
+	lines := strings.Split(headers, "\r\n")
+	for i, line := range lines {
+		name, _, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		for _, sensitive := range sensitiveHeaders {
+			if strings.EqualFold(strings.TrimSpace(name), sensitive) {
+				lines[i] = name + ": REDACTED"
+			}
+		}
+	}
+	return strings.Join(lines, "\r\n")
+}
+
+// quoteArg single-quotes an argument when the shell would otherwise split
+// or expand it
+func quoteArg(arg string) string {
+	if arg == "" || strings.ContainsAny(arg, " \t\r\n'\"$&;|<>()*?") {
+		return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+	}
+	return arg
+}
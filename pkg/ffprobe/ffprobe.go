@@ -4,17 +4,44 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os/exec"
+	"runtime"
 	"strconv"
+	"time"
 )
 
+// FFProbe runs ffprobe commands. Configure it with the Set methods before
+// first use; after that it is safe for concurrent use, as every probe starts
+// its own process and only reads the configuration.
 type FFProbe struct {
-	binary string
+	binary        string
+	commandWriter io.Writer
+	inputFormat   string
+	stdin         io.Reader // Read by probes of StdinInput; nil uses os.Stdin
+	headers       []string  // Extra HTTP headers, "Name: value"
+	userAgent     string
+	retries       int           // Extra attempts for transient network failures
+	retryDelay    time.Duration // Wait before the first retry, doubled after each
+	captureLog    bool          // Run at -v warning and keep ffprobe's log lines
+	probeSize     int64         // -probesize in bytes; 0 keeps ffprobe's default
+	analyzeDur    time.Duration // -analyzeduration; 0 keeps ffprobe's default
+	selectStreams string        // -select_streams for packet and frame probes; empty probes every stream
 }
 
 type ProbeData struct {
-	Streams []Stream `json:"streams"`
-	Format  *Format  `json:"format"`
+	Streams  []Stream  `json:"streams"`
+	Format   *Format   `json:"format"`
+	Programs []Program `json:"programs,omitempty"`
+	Warnings []string  `json:"-"` // ffprobe log lines, when captured
+}
+
+// Program is an ffprobe program. For HLS and DASH inputs each variant
+// (rendition) of the manifest is demuxed as one program.
+type Program struct {
+	ProgramID int               `json:"program_id"`
+	Tags      map[string]string `json:"tags,omitempty"`
+	Streams   []Stream          `json:"streams"`
 }
 
 type Stream struct {
@@ -51,17 +78,55 @@ type Stream struct {
 	BitRate            string            `json:"bit_rate,omitempty"`
 	BitsPerRawSample   string            `json:"bits_per_raw_sample,omitempty"`
 	NbFrames           string            `json:"nb_frames,omitempty"`
+	SampleAspectRatio  string            `json:"sample_aspect_ratio,omitempty"`
 	DisplayAspectRatio string            `json:"display_aspect_ratio,omitempty"`
 	SampleFmt          string            `json:"sample_fmt,omitempty"`
 	SampleRate         string            `json:"sample_rate,omitempty"`
 	Channels           int               `json:"channels,omitempty"`
 	ChannelLayout      string            `json:"channel_layout,omitempty"`
 	BitsPerSample      int               `json:"bits_per_sample,omitempty"`
+	InitialPadding     int               `json:"initial_padding,omitempty"`
+	Disposition        Disposition       `json:"disposition"`
+	SideDataList       []SideData        `json:"side_data_list,omitempty"`
 	Tags               map[string]string `json:"tags,omitempty"`
 	Bitrate            int64
 	NbFramesInt        int64
 }
 
+// Disposition holds the ffprobe disposition flags of a stream (0 or 1)
+type Disposition struct {
+	Default         int `json:"default"`
+	Dub             int `json:"dub"`
+	Original        int `json:"original"`
+	Comment         int `json:"comment"`
+	Forced          int `json:"forced"`
+	HearingImpaired int `json:"hearing_impaired"`
+	VisualImpaired  int `json:"visual_impaired"`
+	AttachedPic     int `json:"attached_pic"` // Cover art rather than video
+	Captions        int `json:"captions"`
+	Descriptions    int `json:"descriptions"`
+}
+
+// SideData is an entry of a stream's side_data_list. Only the fields of the
+// HDR static metadata and stereo 3D entries are decoded.
+type SideData struct {
+	SideDataType string `json:"side_data_type"`
+	MaxLuminance string `json:"max_luminance,omitempty"` // Rational, e.g. "10000000/10000"
+	MinLuminance string `json:"min_luminance,omitempty"`
+	MaxContent   int    `json:"max_content,omitempty"`
+	MaxAverage   int    `json:"max_average,omitempty"`
+	Type         string `json:"type,omitempty"`     // Stereo 3D layout, e.g. "side by side"
+	Inverted     int    `json:"inverted,omitempty"` // Stereo 3D views are swapped (right eye first)
+}
+
+// Side data types reported by ffprobe for HDR10 static metadata and
+// stereoscopic 3D layout
+const (
+	SideDataMasteringDisplay = "Mastering display metadata"
+	SideDataContentLight     = "Content light level metadata"
+	SideDataStereo3D         = "Stereo 3D"
+)
+
 type Format struct {
 	Filename       string            `json:"filename"`
 	NbStreams      int               `json:"nb_streams"`
@@ -78,24 +143,48 @@ type Format struct {
 	SizeInt        int64
 }
 
+// DefaultBinary is the ffprobe executable looked up on PATH when no other
+// binary is configured
+const DefaultBinary = "ffprobe"
+
 func New() *FFProbe {
 	return &FFProbe{
-		binary: "ffprobe",
+		binary:     DefaultBinary,
+		retryDelay: DefaultRetryDelay,
+	}
+}
+
+// NewWithBinary returns a prober that runs the ffprobe executable at path
+func NewWithBinary(path string) *FFProbe {
+	f := New()
+	f.SetBinary(path)
+	return f
+}
+
+// SetBinary sets the ffprobe executable to run, either a path or a name
+// looked up on PATH. Pass "" to use DefaultBinary.
+func (f *FFProbe) SetBinary(path string) {
+	if path == "" {
+		path = DefaultBinary
 	}
+	f.binary = path
 }
 
 func (f *FFProbe) Probe(ctx context.Context, input string) (*ProbeData, error) {
 	args := []string{
-		"-v", "quiet",
+		"-v", f.logLevel(),
 		"-print_format", "json",
 		"-show_format",
 		"-show_streams",
-		input,
+		"-show_programs",
 	}
+	args = f.appendInput(args, input)
 
-	cmd := exec.CommandContext(ctx, f.binary, args...)
-	output, err := cmd.Output()
+	output, warnings, err := f.output(ctx, input, args)
 	if err != nil {
+		if err == ErrCancelled {
+			return nil, err
+		}
 		if exitErr, ok := err.(*exec.ExitError); ok {
 			return nil, fmt.Errorf("ffprobe failed: %s", string(exitErr.Stderr))
 		}
@@ -106,6 +195,7 @@ func (f *FFProbe) Probe(ctx context.Context, input string) (*ProbeData, error) {
 	if err := json.Unmarshal(output, &data); err != nil {
 		return nil, fmt.Errorf("failed to parse ffprobe output: %w", err)
 	}
+	data.Warnings = warnings
 
 	for i := range data.Streams {
 		stream := &data.Streams[i]
@@ -145,15 +235,18 @@ func (f *FFProbe) Probe(ctx context.Context, input string) (*ProbeData, error) {
 // ProbePackets extracts packet information from media file
 func (f *FFProbe) ProbePackets(ctx context.Context, input string) (*PacketsData, error) {
 	args := []string{
-		"-v", "quiet",
+		"-v", f.logLevel(),
 		"-print_format", "json",
 		"-show_packets",
-		input,
 	}
+	args = f.appendSelectStreams(args)
+	args = f.appendInput(args, input)
 
-	cmd := exec.CommandContext(ctx, f.binary, args...)
-	output, err := cmd.Output()
+	output, warnings, err := f.output(ctx, input, args)
 	if err != nil {
+		if err == ErrCancelled {
+			return nil, err
+		}
 		if exitErr, ok := err.(*exec.ExitError); ok {
 			return nil, fmt.Errorf("ffprobe packets failed: %s", string(exitErr.Stderr))
 		}
@@ -164,47 +257,55 @@ func (f *FFProbe) ProbePackets(ctx context.Context, input string) (*PacketsData,
 	if err := json.Unmarshal(output, &data); err != nil {
 		return nil, fmt.Errorf("failed to parse packets output: %w", err)
 	}
+	data.Warnings = warnings
 
-	// Convert string timestamps to float64
 	for i := range data.Packets {
-		packet := &data.Packets[i]
-		if packet.PTSTime != "" {
-			if pts, err := strconv.ParseFloat(packet.PTSTime, 64); err == nil {
-				packet.PTS = pts
-			}
+		data.Packets[i].parseFields()
+	}
+
+	return &data, nil
+}
+
+// parseFields converts the packet's string timestamps and size to numbers
+func (packet *Packet) parseFields() {
+	if packet.PTSTime != "" {
+		if pts, err := strconv.ParseFloat(packet.PTSTime, 64); err == nil {
+			packet.PTS = pts
+			packet.HasPTS = true
 		}
-		if packet.DTSTime != "" {
-			if dts, err := strconv.ParseFloat(packet.DTSTime, 64); err == nil {
-				packet.DTS = dts
-			}
+	}
+	if packet.DTSTime != "" {
+		if dts, err := strconv.ParseFloat(packet.DTSTime, 64); err == nil {
+			packet.DTS = dts
 		}
-		if packet.DurationTime != "" {
-			if duration, err := strconv.ParseFloat(packet.DurationTime, 64); err == nil {
-				packet.Duration = duration
-			}
+	}
+	if packet.DurationTime != "" {
+		if duration, err := strconv.ParseFloat(packet.DurationTime, 64); err == nil {
+			packet.Duration = duration
 		}
-		if packet.SizeStr != "" {
-			if size, err := strconv.Atoi(packet.SizeStr); err == nil {
-				packet.Size = size
-			}
+	}
+	if packet.SizeStr != "" {
+		if size, err := strconv.Atoi(packet.SizeStr); err == nil {
+			packet.Size = size
 		}
 	}
-
-	return &data, nil
 }
 
 // ProbeFrames extracts frame information from media file
 func (f *FFProbe) ProbeFrames(ctx context.Context, input string) (*FramesData, error) {
 	args := []string{
-		"-v", "quiet",
+		"-v", f.logLevel(),
 		"-print_format", "json",
 		"-show_frames",
-		input,
 	}
+	args = f.appendSelectStreams(args)
+	args = f.appendInput(args, input)
 
-	cmd := exec.CommandContext(ctx, f.binary, args...)
-	output, err := cmd.Output()
+	output, warnings, err := f.output(ctx, input, args)
 	if err != nil {
+		if err == ErrCancelled {
+			return nil, err
+		}
 		if exitErr, ok := err.(*exec.ExitError); ok {
 			return nil, fmt.Errorf("ffprobe frames failed: %s", string(exitErr.Stderr))
 		}
@@ -215,6 +316,7 @@ func (f *FFProbe) ProbeFrames(ctx context.Context, input string) (*FramesData, e
 	if err := json.Unmarshal(output, &data); err != nil {
 		return nil, fmt.Errorf("failed to parse frames output: %w", err)
 	}
+	data.Warnings = warnings
 
 	// Convert string values to appropriate types
 	for i := range data.Frames {
@@ -247,7 +349,8 @@ func (f *FFProbe) ProbeFrames(ctx context.Context, input string) (*FramesData, e
 
 // PacketsData holds packet information
 type PacketsData struct {
-	Packets []Packet `json:"packets"`
+	Packets  []Packet `json:"packets"`
+	Warnings []string `json:"-"` // ffprobe log lines, when captured
 }
 
 // Packet represents a media packet
@@ -256,6 +359,7 @@ type Packet struct {
 	StreamIndex  int     `json:"stream_index"`
 	PTS          float64 `json:"-"`
 	PTSTime      string  `json:"pts_time"`
+	HasPTS       bool    `json:"-"` // PTSTime held a time; PTS is 0 when it did not
 	DTS          float64 `json:"-"`
 	DTSTime      string  `json:"dts_time"`
 	Duration     float64 `json:"-"`
@@ -268,7 +372,8 @@ type Packet struct {
 
 // FramesData holds frame information
 type FramesData struct {
-	Frames []Frame `json:"frames"`
+	Frames   []Frame  `json:"frames"`
+	Warnings []string `json:"-"` // ffprobe log lines, when captured
 }
 
 // Frame represents a media frame
@@ -299,7 +404,10 @@ type Frame struct {
 func (f *FFProbe) CheckInstalled() error {
 	cmd := exec.Command(f.binary, "-version")
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("ffprobe not found. Please install FFmpeg: %w", err)
+		if f.binary != DefaultBinary {
+			return fmt.Errorf("ffprobe not usable at %s (%w)", f.binary, err)
+		}
+		return fmt.Errorf("ffprobe not found (%w)\n%s", err, InstallInstructions(runtime.GOOS))
 	}
 	return nil
 }
\ No newline at end of file
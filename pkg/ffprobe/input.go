@@ -0,0 +1,179 @@
+package ffprobe
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// InputFormats are the demuxer names accepted as input format hints
+var InputFormats = map[string]string{
+	"mpegts":   "MPEG transport stream",
+	"mpeg":     "MPEG program stream",
+	"mp4":      "MP4 / ISO BMFF",
+	"mov":      "QuickTime / MOV",
+	"matroska": "Matroska / WebM",
+	"webm":     "WebM",
+	"flv":      "Flash Video",
+	"avi":      "AVI",
+	"mxf":      "MXF",
+	"ogg":      "Ogg",
+	"wav":      "WAV",
+	"hls":      "HTTP Live Streaming playlist",
+	"dash":     "MPEG-DASH manifest",
+	"rtsp":     "RTSP",
+	"h264":     "raw H.264 elementary stream",
+	"hevc":     "raw HEVC elementary stream",
+	"aac":      "raw ADTS AAC",
+	"mp3":      "MP3",
+	"flac":     "FLAC",
+	"ac3":      "raw AC-3",
+	"eac3":     "raw E-AC-3",
+	"m4v":      "raw MPEG-4 video",
+	"ivf":      "IVF (VP8/VP9/AV1)",
+	"obu":      "raw AV1 OBU stream",
+}
+
+// InputFormatNames returns the allowed input format hints, sorted
+func InputFormatNames() []string {
+	names := make([]string, 0, len(InputFormats))
+	for name := range InputFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetInputFormat forces ffprobe's demuxer (-f) for every probe. Pass "" to
+// let ffprobe detect the format.
+func (f *FFProbe) SetInputFormat(format string) {
+	f.inputFormat = format
+}
+
+// StdinInput is the input name that makes ffprobe read from standard input.
+// Stdin can only be read once, so only a single probe may use it.
+const StdinInput = "-"
+
+// SetStdin sets the reader piped to ffprobe when probing StdinInput. Pass
+// nil to use os.Stdin.
+func (f *FFProbe) SetStdin(r io.Reader) {
+	f.stdin = r
+}
+
+// SetHeaders sets extra HTTP headers, each "Name: value", sent when probing
+// HTTP(S) inputs
+func (f *FFProbe) SetHeaders(headers []string) {
+	f.headers = headers
+}
+
+// SetUserAgent overrides the User-Agent sent when probing HTTP(S) inputs.
+// Pass "" to keep ffprobe's default.
+func (f *FFProbe) SetUserAgent(userAgent string) {
+	f.userAgent = userAgent
+}
+
+// isHTTPInput reports whether input is fetched over HTTP(S), the only
+// protocol that accepts -headers and -user_agent
+func isHTTPInput(input string) bool {
+	lower := strings.ToLower(input)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// SetProbeSize sets how many bytes ffprobe reads to detect the streams
+// (-probesize). Pass 0 to keep ffprobe's default.
+func (f *FFProbe) SetProbeSize(bytes int64) {
+	f.probeSize = bytes
+}
+
+// SetAnalyzeDuration sets how much of the input ffprobe analyzes to detect
+// the streams (-analyzeduration). Pass 0 to keep ffprobe's default.
+func (f *FFProbe) SetAnalyzeDuration(d time.Duration) {
+	f.analyzeDur = d
+}
+
+// SetSelectStreams restricts packet and frame probes to the streams matching
+// an ffprobe stream specifier (-select_streams), e.g. "3" or "v:0". Pass ""
+// to probe every stream. Probe always reports every stream.
+func (f *FFProbe) SetSelectStreams(spec string) {
+	f.selectStreams = spec
+}
+
+// appendSelectStreams adds -select_streams when a stream selection is set
+func (f *FFProbe) appendSelectStreams(args []string) []string {
+	if f.selectStreams == "" {
+		return args
+	}
+	return append(args, "-select_streams", f.selectStreams)
+}
+
+// appendInput adds the input, preceded by the demuxer hint and HTTP options
+// when they are set
+func (f *FFProbe) appendInput(args []string, input string) []string {
+	if f.inputFormat != "" {
+		args = append(args, "-f", f.inputFormat)
+	}
+	// Demuxer options only apply to the input that follows them
+	if f.probeSize > 0 {
+		args = append(args, "-probesize", strconv.FormatInt(f.probeSize, 10))
+	}
+	if f.analyzeDur > 0 {
+		args = append(args, "-analyzeduration", strconv.FormatInt(f.analyzeDur.Microseconds(), 10))
+	}
+	// ffprobe rejects protocol options the input's protocol does not use
+	if isHTTPInput(input) {
+		if len(f.headers) > 0 {
+			// ffprobe expects one CRLF-terminated line per header
+			args = append(args, "-headers", strings.Join(f.headers, "\r\n")+"\r\n")
+		}
+		if f.userAgent != "" {
+			args = append(args, "-user_agent", f.userAgent)
+		}
+	}
+	if input == StdinInput {
+		input = "pipe:0"
+	}
+	return append(args, input)
+}
+
+// attachStdin connects the configured stdin to cmd when probing StdinInput
+func (f *FFProbe) attachStdin(cmd *exec.Cmd, input string) {
+	if input != StdinInput {
+		return
+	}
+	cmd.Stdin = f.stdin
+	if cmd.Stdin == nil {
+		cmd.Stdin = os.Stdin
+	}
+}
+
+// Demuxers returns the demuxer names supported by the installed ffprobe,
+// parsed from `ffprobe -demuxers`
+func (f *FFProbe) Demuxers(ctx context.Context) (map[string]bool, error) {
+	output, err := exec.CommandContext(ctx, f.binary, "-hide_banner", "-demuxers").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ffprobe demuxers: %w", err)
+	}
+
+	// Lines look like " D  mpegts          MPEG-TS (MPEG-2 Transport Stream)";
+	// a demuxer line may list several comma-separated names
+	demuxers := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || !strings.Contains(fields[0], "D") || fields[1] == "=" {
+			continue
+		}
+		for _, name := range strings.Split(fields[1], ",") {
+			demuxers[name] = true
+		}
+	}
+	return demuxers, scanner.Err()
+}
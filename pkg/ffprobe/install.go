@@ -0,0 +1,62 @@
+package ffprobe
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// InstallCommand returns the package manager command that installs FFmpeg
+// on the given OS (as reported by runtime.GOOS), or nil when no supported
+// package manager is available
+func InstallCommand(goos string) []string {
+	switch goos {
+	case "darwin":
+		if _, err := exec.LookPath("brew"); err == nil {
+			return []string{"brew", "install", "ffmpeg"}
+		}
+	case "linux":
+		for _, manager := range [][]string{
+			{"sudo", "apt-get", "install", "-y", "ffmpeg"},
+			{"sudo", "dnf", "install", "-y", "ffmpeg"},
+			{"sudo", "pacman", "-S", "--noconfirm", "ffmpeg"},
+		} {
+			if _, err := exec.LookPath(manager[1]); err == nil {
+				return manager
+			}
+		}
+	case "windows":
+		if _, err := exec.LookPath("winget"); err == nil {
+			return []string{"winget", "install", "--id", "Gyan.FFmpeg", "-e"}
+		}
+		if _, err := exec.LookPath("choco"); err == nil {
+			return []string{"choco", "install", "-y", "ffmpeg"}
+		}
+	}
+	return nil
+}
+
+// InstallInstructions returns human-readable FFmpeg install instructions
+// for the given OS
+func InstallInstructions(goos string) string {
+	var b strings.Builder
+	b.WriteString("FFmpeg (which provides ffprobe) is required. To install it:\n")
+
+	switch goos {
+	case "darwin":
+		b.WriteString("  brew install ffmpeg\n")
+	case "linux":
+		b.WriteString("  Debian/Ubuntu: sudo apt-get install ffmpeg\n")
+		b.WriteString("  Fedora:        sudo dnf install ffmpeg\n")
+		b.WriteString("  Arch:          sudo pacman -S ffmpeg\n")
+	case "windows":
+		b.WriteString("  winget install --id Gyan.FFmpeg -e\n")
+		b.WriteString("  or: choco install ffmpeg\n")
+	default:
+		b.WriteString("  Download a build from https://ffmpeg.org/download.html\n")
+	}
+
+	if command := InstallCommand(goos); command != nil {
+		b.WriteString("Or re-run with --install-ffmpeg to run `" + strings.Join(command, " ") + "` now.\n")
+	}
+	return b.String()
+}
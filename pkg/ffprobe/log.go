@@ -0,0 +1,38 @@
+package ffprobe
+
+import (
+	"regexp"
+	"strings"
+)
+
+// SetCaptureLog runs probes at -v warning instead of -v error and keeps
+// ffprobe's log lines (such as "non-monotonous DTS" or "moov atom not
+// found") on the results' Warnings
+func (f *FFProbe) SetCaptureLog(capture bool) {
+	f.captureLog = capture
+}
+
+// logLevel returns the -v level probes run at
+func (f *FFProbe) logLevel() string {
+	if f.captureLog {
+		return "warning"
+	}
+	return "error"
+}
+
+// contextAddress matches the " @ 0x55d1c2a3b4c0" ffmpeg appends to the
+// component name of a log line, which differs on every run
+var contextAddress = regexp.MustCompile(` @ 0x[0-9a-f]+\]`)
+
+// logLines splits captured stderr into trimmed, non-empty lines with the
+// per-run context addresses removed, so repeated messages compare equal
+func logLines(stderr []byte) []string {
+	var lines []string
+	for _, line := range strings.Split(string(stderr), "\n") {
+		line = strings.TrimSpace(contextAddress.ReplaceAllString(line, "]"))
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,29 @@
+package ffprobe
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseRational converts an ffprobe rational such as "30000/1001" or "25/1"
+// to a float64. A plain number is accepted as well. A zero denominator
+// ("0/0" for an undefined frame rate) is an error rather than NaN or Inf.
+func ParseRational(s string) (float64, error) {
+	num, den, found := strings.Cut(strings.TrimSpace(s), "/")
+	n, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid rational %q", s)
+	}
+	if !found {
+		return n, nil
+	}
+	d, err := strconv.ParseFloat(den, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid rational %q", s)
+	}
+	if d == 0 {
+		return 0, fmt.Errorf("undefined rational %q: zero denominator", s)
+	}
+	return n / d, nil
+}
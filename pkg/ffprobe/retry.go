@@ -0,0 +1,124 @@
+package ffprobe
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// DefaultRetryDelay is the wait before the first retry when none is set
+const DefaultRetryDelay = time.Second
+
+// ErrCancelled is returned by probes whose context was cancelled, e.g. on
+// Ctrl-C; the ffprobe process has been killed by then
+var ErrCancelled = errors.New("analysis cancelled")
+
+// killWait bounds how long a killed ffprobe may keep its output pipes open
+// (e.g. through a child process) before the probe returns anyway
+const killWait = 2 * time.Second
+
+// permanentErrors are ffprobe stderr fragments of network failures that
+// retrying cannot fix. "Invalid data found when processing input" is not
+// among them: from a server it is often a truncated or interrupted response.
+// Local files, where it is permanent, are never retried anyway.
+var permanentErrors = []string{
+	"no such file or directory",
+	"permission denied",
+	"protocol not found",
+	"invalid argument",
+	"400 bad request",
+	"401 unauthorized",
+	"403 forbidden",
+	"404 not found",
+	"410 gone",
+}
+
+// SetRetries makes probes of network inputs retry up to retries times after
+// a transient failure, waiting delay before the first retry and doubling it
+// after each. Pass a delay of 0 to use DefaultRetryDelay.
+func (f *FFProbe) SetRetries(retries int, delay time.Duration) {
+	if delay <= 0 {
+		delay = DefaultRetryDelay
+	}
+	f.retries = retries
+	f.retryDelay = delay
+}
+
+// output runs ffprobe with args and returns its stdout and, when the log is
+// captured, its log lines. Transient failures of network inputs are retried
+// with exponential backoff, never waiting past the context deadline; the
+// last error is returned as-is, with stderr on its *exec.ExitError.
+func (f *FFProbe) output(ctx context.Context, input string, args []string) ([]byte, []string, error) {
+	delay := f.retryDelay
+	for attempt := 0; ; attempt++ {
+		f.logCommand(args)
+		cmd := exec.CommandContext(ctx, f.binary, args...)
+		cmd.WaitDelay = killWait
+		f.attachStdin(cmd, input)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		output, err := cmd.Output()
+		if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+			return nil, nil, ErrCancelled
+		}
+
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			// Output only fills in Stderr when it captures stderr itself
+			exitErr.Stderr = stderr.Bytes()
+		}
+		var warnings []string
+		if f.captureLog {
+			warnings = logLines(stderr.Bytes())
+		}
+		if err == nil || attempt >= f.retries || !retryable(ctx, input, err) {
+			return output, warnings, err
+		}
+
+		if !waitRetry(ctx, delay) {
+			return output, warnings, err
+		}
+		delay *= 2
+	}
+}
+
+// waitRetry waits delay before a retry. It returns false without waiting
+// when the context deadline would pass first, or early when the context is
+// done.
+func waitRetry(ctx context.Context, delay time.Duration) bool {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= delay {
+		return false
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// retryable reports whether a failed probe of input may succeed when run
+// again. Only network inputs fail transiently; stdin cannot be re-read, and
+// a missing binary, cancelled context or permanent ffprobe error will fail
+// the same way again.
+func retryable(ctx context.Context, input string, err error) bool {
+	if ctx.Err() != nil || input == StdinInput || !strings.Contains(input, "://") {
+		return false
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return false
+	}
+	stderr := strings.ToLower(string(exitErr.Stderr))
+	for _, permanent := range permanentErrors {
+		if strings.Contains(stderr, permanent) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,41 @@
+package ffprobe
+
+import (
+	"context"
+	"os/exec"
+	"runtime"
+	"testing"
+)
+
+func TestRetryable(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+
+	tests := []struct {
+		name   string
+		input  string
+		stderr string
+		want   bool
+	}{
+		{"network timeout", "https://example.com/a.m3u8", "Connection timed out", true},
+		{"network invalid data", "https://example.com/a.m3u8", "Invalid data found when processing input", true},
+		{"network 404", "https://example.com/a.m3u8", "Server returned 404 Not Found", false},
+		{"local invalid data", "/media/a.mp4", "Invalid data found when processing input", false},
+		{"stdin", StdinInput, "Connection timed out", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := exec.Command("sh", "-c", "exit 1")
+			err := cmd.Run()
+			exitErr, ok := err.(*exec.ExitError)
+			if !ok {
+				t.Fatalf("got %v, want an *exec.ExitError", err)
+			}
+			exitErr.Stderr = []byte(tt.input + ": " + tt.stderr + "\n")
+			if got := retryable(context.Background(), tt.input, exitErr); got != tt.want {
+				t.Errorf("got %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
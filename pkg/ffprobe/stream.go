@@ -0,0 +1,128 @@
+package ffprobe
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+)
+
+// StreamPackets runs ffprobe -show_packets and calls fn with each packet as
+// it is decoded from ffprobe's output, so the packet list is never held in
+// memory as a whole. When fn returns false the ffprobe process is killed and
+// StreamPackets returns without error. A probe that fails before delivering
+// any packet is retried as configured with SetRetries. The returned log
+// lines are only set when the log is captured.
+func (f *FFProbe) StreamPackets(ctx context.Context, input string, fn func(Packet) bool) ([]string, error) {
+	args := []string{
+		"-v", f.logLevel(),
+		"-print_format", "json",
+		"-show_packets",
+	}
+	args = f.appendSelectStreams(args)
+	args = f.appendInput(args, input)
+
+	delay := f.retryDelay
+	for attempt := 0; ; attempt++ {
+		delivered, warnings, err := f.streamPacketsOnce(ctx, input, args, fn)
+		if err == nil || delivered > 0 || attempt >= f.retries || !retryable(ctx, input, err) || !waitRetry(ctx, delay) {
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				return warnings, fmt.Errorf("ffprobe packets failed: %s", string(exitErr.Stderr))
+			}
+			return warnings, err
+		}
+		delay *= 2
+	}
+}
+
+// streamPacketsOnce runs a single StreamPackets attempt, returning how many
+// packets reached fn
+func (f *FFProbe) streamPacketsOnce(ctx context.Context, input string, args []string, fn func(Packet) bool) (int, []string, error) {
+	probeCtx, kill := context.WithCancel(ctx)
+	defer kill()
+
+	f.logCommand(args)
+	cmd := exec.CommandContext(probeCtx, f.binary, args...)
+	cmd.WaitDelay = killWait
+	f.attachStdin(cmd, input)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to run ffprobe for packets: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return 0, nil, fmt.Errorf("failed to run ffprobe for packets: %w", err)
+	}
+
+	delivered, stopped, decodeErr := decodePackets(stdout, fn)
+	if stopped {
+		// Stop ffprobe instead of reading the rest of its output
+		kill()
+	} else if decodeErr != nil {
+		// Let ffprobe finish so a failed run reports its own error
+		io.Copy(io.Discard, stdout)
+	}
+	waitErr := cmd.Wait()
+
+	var warnings []string
+	if f.captureLog {
+		warnings = logLines(stderr.Bytes())
+	}
+	if stopped {
+		return delivered, warnings, nil
+	}
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return delivered, warnings, ErrCancelled
+	}
+	var exitErr *exec.ExitError
+	if errors.As(waitErr, &exitErr) {
+		exitErr.Stderr = stderr.Bytes()
+		return delivered, warnings, waitErr
+	}
+	if waitErr != nil {
+		return delivered, warnings, fmt.Errorf("failed to run ffprobe for packets: %w", waitErr)
+	}
+	if decodeErr != nil {
+		return delivered, warnings, fmt.Errorf("failed to parse packets output: %w", decodeErr)
+	}
+	return delivered, warnings, nil
+}
+
+// decodePackets reads ffprobe's {"packets": [...]} output one packet at a
+// time. stopped is true when fn asked to stop.
+func decodePackets(r io.Reader, fn func(Packet) bool) (delivered int, stopped bool, err error) {
+	decoder := json.NewDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return delivered, false, nil
+		}
+		if err != nil {
+			return delivered, false, err
+		}
+		if key, ok := token.(string); !ok || key != "packets" {
+			continue
+		}
+
+		// Opening bracket of the packet array
+		if _, err := decoder.Token(); err != nil {
+			return delivered, false, err
+		}
+		for decoder.More() {
+			var packet Packet
+			if err := decoder.Decode(&packet); err != nil {
+				return delivered, false, err
+			}
+			packet.parseFields()
+			delivered++
+			if !fn(packet) {
+				return delivered, true, nil
+			}
+		}
+	}
+}